@@ -3,9 +3,11 @@
 package hcn
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"syscall"
+	"time"
 
 	"github.com/Microsoft/go-winio/pkg/guid"
 )
@@ -14,6 +16,7 @@ import (
 
 /// HNS V1 API
 
+//sys GetCurrentThreadCompartmentId() (id uint32) = iphlpapi.GetCurrentThreadCompartmentId
 //sys SetCurrentThreadCompartmentId(compartmentId uint32) (hr error) = iphlpapi.SetCurrentThreadCompartmentId
 //sys _hnsCall(method string, path string, object string, response **uint16) (hr error) = vmcompute.HNSCall?
 
@@ -107,6 +110,19 @@ type Health struct {
 	Extra ExtraParams `json:",omitempty"`
 }
 
+// DefaultTimeout bounds the context-less convenience wrappers of the
+// context-aware APIs in this package. Those wrappers used to block until the
+// underlying operation returned; they now give up after DefaultTimeout.
+// Callers can tune it globally, or use the context variants directly to
+// override it per call.
+var DefaultTimeout = 30 * time.Second
+
+// defaultContext returns the context used by the context-less convenience
+// wrappers, bounded by DefaultTimeout.
+func defaultContext() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.Background(), DefaultTimeout)
+}
+
 // defaultQuery generates HCN Query.
 // Passed into get/enumerate calls to filter results.
 func defaultQuery() HostComputeQuery {
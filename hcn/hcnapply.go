@@ -0,0 +1,255 @@
+//go:build windows
+
+package hcn
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/Microsoft/go-winio/pkg/guid"
+	"github.com/sirupsen/logrus"
+)
+
+// ErrSpecMismatch is returned by Apply when an object in the spec already
+// exists but differs from it, and is of a type Apply cannot update in place.
+// The caller must either fix the spec or delete and re-create the object.
+var ErrSpecMismatch = errors.New("existing object does not match the spec")
+
+// HNSSpec is a declarative desired-state description of a set of HNS
+// objects. Apply walks the slices in dependency order, so objects may
+// reference others in the same spec (an endpoint its network, a load balancer
+// its endpoints) as long as the referenced object carries a fixed ID.
+type HNSSpec struct {
+	Networks      []*HostComputeNetwork      `json:",omitempty"`
+	Endpoints     []*HostComputeEndpoint     `json:",omitempty"`
+	Namespaces    []*HostComputeNamespace    `json:",omitempty"`
+	LoadBalancers []*HostComputeLoadBalancer `json:",omitempty"`
+	Routes        []*HostComputeRoute        `json:",omitempty"`
+}
+
+// ApplyAction records what Apply did with one object of a spec.
+type ApplyAction string
+
+const (
+	// ApplyActionCreated means the object did not exist and was created.
+	ApplyActionCreated ApplyAction = "Created"
+	// ApplyActionUpdated means the object existed and was modified to
+	// match the spec.
+	ApplyActionUpdated ApplyAction = "Updated"
+	// ApplyActionUnchanged means the object existed and already matched
+	// the spec.
+	ApplyActionUnchanged ApplyAction = "Unchanged"
+	// ApplyActionFailed means the object could not be brought to the
+	// desired state; its error is in the outcome.
+	ApplyActionFailed ApplyAction = "Failed"
+)
+
+// ObjectOutcome is the per-object result of an Apply.
+type ObjectOutcome struct {
+	Type   ObjectType
+	Id     string
+	Action ApplyAction
+	Err    error
+}
+
+// ApplyResult reports what Apply did, one outcome per spec object in the
+// order they were processed. RolledBack is set when a failure caused the
+// objects created by this Apply to be deleted again.
+type ApplyResult struct {
+	Outcomes   []ObjectOutcome
+	RolledBack bool
+}
+
+// Apply brings the host to the desired state described by spec. Objects are
+// processed in dependency order — networks, endpoints, namespaces, load
+// balancers, routes — creating those that do not exist, reconciling existing
+// endpoints with the minimal modifies DiffEndpoint computes, and adopting
+// existing objects of the other types only when they already match the spec;
+// HNS has no general modify for those, so one that differs fails the Apply
+// with ErrSpecMismatch rather than being misreported as Unchanged.
+// On the first failure the objects this call created
+// are deleted again in reverse order, so a failed Apply does not leave a
+// partially constructed spec behind; objects that already existed are never
+// touched by the rollback. The returned result carries a per-object outcome
+// either way.
+func Apply(spec HNSSpec) (ApplyResult, error) {
+	logrus.Debugf("hcn::Apply networks=%d endpoints=%d namespaces=%d loadBalancers=%d routes=%d",
+		len(spec.Networks), len(spec.Endpoints), len(spec.Namespaces), len(spec.LoadBalancers), len(spec.Routes))
+
+	var result ApplyResult
+	var created []func() error
+
+	fail := func(objType ObjectType, id string, err error) (ApplyResult, error) {
+		result.Outcomes = append(result.Outcomes, ObjectOutcome{Type: objType, Id: id, Action: ApplyActionFailed, Err: err})
+		errs := []error{fmt.Errorf("%s %s: %w", objType, id, err)}
+		for i := len(created) - 1; i >= 0; i-- {
+			if rollbackErr := created[i](); rollbackErr != nil {
+				errs = append(errs, fmt.Errorf("rollback: %w", rollbackErr))
+			}
+		}
+		result.RolledBack = len(created) > 0
+		return result, errors.Join(errs...)
+	}
+	succeed := func(objType ObjectType, id string, action ApplyAction, deleteFn func() error) {
+		result.Outcomes = append(result.Outcomes, ObjectOutcome{Type: objType, Id: id, Action: action})
+		if action == ApplyActionCreated {
+			created = append(created, deleteFn)
+		}
+	}
+
+	for _, network := range spec.Networks {
+		if network.Id != "" {
+			existing, err := GetNetworkByID(network.Id)
+			if err == nil {
+				matches, err := specMatches(existing, network)
+				if err != nil {
+					return fail(ObjectTypeNetwork, existing.Id, err)
+				}
+				if !matches {
+					return fail(ObjectTypeNetwork, existing.Id, ErrSpecMismatch)
+				}
+				succeed(ObjectTypeNetwork, existing.Id, ApplyActionUnchanged, nil)
+				continue
+			}
+			if !IsNotFoundError(err) {
+				return fail(ObjectTypeNetwork, network.Id, err)
+			}
+		}
+		createdNetwork, err := network.Create()
+		if err != nil {
+			return fail(ObjectTypeNetwork, network.Id, err)
+		}
+		succeed(ObjectTypeNetwork, createdNetwork.Id, ApplyActionCreated, createdNetwork.Delete)
+	}
+
+	for _, endpoint := range spec.Endpoints {
+		if endpointGUID, err := guid.FromString(endpoint.Id); err == nil {
+			ensured, wasCreated, wasChanged, err := ensureEndpoint(endpointGUID, endpoint)
+			if err != nil {
+				return fail(ObjectTypeEndpoint, endpoint.Id, err)
+			}
+			action := ApplyActionUnchanged
+			if wasCreated {
+				action = ApplyActionCreated
+			} else if wasChanged {
+				action = ApplyActionUpdated
+			}
+			succeed(ObjectTypeEndpoint, ensured.Id, action, ensured.Delete)
+			continue
+		}
+		createdEndpoint, err := endpoint.Create()
+		if err != nil {
+			return fail(ObjectTypeEndpoint, endpoint.Id, err)
+		}
+		succeed(ObjectTypeEndpoint, createdEndpoint.Id, ApplyActionCreated, createdEndpoint.Delete)
+	}
+
+	for _, namespace := range spec.Namespaces {
+		if namespace.Id != "" {
+			existing, err := GetNamespaceByID(namespace.Id)
+			if err == nil {
+				matches, err := specMatches(existing, namespace)
+				if err != nil {
+					return fail(ObjectTypeNamespace, existing.Id, err)
+				}
+				if !matches {
+					return fail(ObjectTypeNamespace, existing.Id, ErrSpecMismatch)
+				}
+				succeed(ObjectTypeNamespace, existing.Id, ApplyActionUnchanged, nil)
+				continue
+			}
+			if !IsNotFoundError(err) {
+				return fail(ObjectTypeNamespace, namespace.Id, err)
+			}
+		}
+		createdNamespace, err := namespace.Create()
+		if err != nil {
+			return fail(ObjectTypeNamespace, namespace.Id, err)
+		}
+		succeed(ObjectTypeNamespace, createdNamespace.Id, ApplyActionCreated, createdNamespace.Delete)
+	}
+
+	for _, loadBalancer := range spec.LoadBalancers {
+		if loadBalancer.Id != "" {
+			existing, err := GetLoadBalancerByID(loadBalancer.Id)
+			if err == nil {
+				matches, err := specMatches(existing, loadBalancer)
+				if err != nil {
+					return fail(ObjectTypeLoadBalancer, existing.Id, err)
+				}
+				if !matches {
+					return fail(ObjectTypeLoadBalancer, existing.Id, ErrSpecMismatch)
+				}
+				succeed(ObjectTypeLoadBalancer, existing.Id, ApplyActionUnchanged, nil)
+				continue
+			}
+			if !IsNotFoundError(err) {
+				return fail(ObjectTypeLoadBalancer, loadBalancer.Id, err)
+			}
+		}
+		createdLoadBalancer, err := loadBalancer.Create()
+		if err != nil {
+			return fail(ObjectTypeLoadBalancer, loadBalancer.Id, err)
+		}
+		succeed(ObjectTypeLoadBalancer, createdLoadBalancer.Id, ApplyActionCreated, createdLoadBalancer.Delete)
+	}
+
+	for _, route := range spec.Routes {
+		if route.ID != "" {
+			existing, err := GetRouteByID(route.ID)
+			if err == nil {
+				matches, err := specMatches(existing, route)
+				if err != nil {
+					return fail(ObjectTypeRoute, existing.ID, err)
+				}
+				if !matches {
+					return fail(ObjectTypeRoute, existing.ID, ErrSpecMismatch)
+				}
+				succeed(ObjectTypeRoute, existing.ID, ApplyActionUnchanged, nil)
+				continue
+			}
+			if !IsNotFoundError(err) {
+				return fail(ObjectTypeRoute, route.ID, err)
+			}
+		}
+		createdRoute, err := route.Create()
+		if err != nil {
+			return fail(ObjectTypeRoute, route.ID, err)
+		}
+		succeed(ObjectTypeRoute, createdRoute.ID, ApplyActionCreated, createdRoute.Delete)
+	}
+
+	return result, nil
+}
+
+// specMatches reports whether existing satisfies every field the spec object
+// declares. Fields the spec leaves at their zero value are not compared, so
+// state the spec author does not write down — health, schema version,
+// HNS-assigned defaults — does not count as a difference. Declared fields are
+// compared through their JSON form, which keeps client-only metadata that
+// never reaches HNS (such as NetworkPolicy.Priority) out of the comparison.
+func specMatches(existing, spec any) (bool, error) {
+	existingValue := reflect.ValueOf(existing).Elem()
+	specValue := reflect.ValueOf(spec).Elem()
+	for i := 0; i < specValue.NumField(); i++ {
+		field := specValue.Field(i)
+		if field.IsZero() {
+			continue
+		}
+		specJSON, err := json.Marshal(field.Interface())
+		if err != nil {
+			return false, err
+		}
+		existingJSON, err := json.Marshal(existingValue.Field(i).Interface())
+		if err != nil {
+			return false, err
+		}
+		if !bytes.Equal(specJSON, existingJSON) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
@@ -0,0 +1,35 @@
+//go:build windows
+
+package hcn
+
+import (
+	"errors"
+	"sync"
+)
+
+// ErrHCNNotAvailable is returned by the public functions in this package when
+// computenetwork.dll cannot be loaded, which happens on SKUs without the
+// containers feature installed. It replaces the per-procedure resolution
+// errors those hosts would otherwise surface on every call.
+var ErrHCNNotAvailable = errors.New("HCN is not installed on this host")
+
+var (
+	// availableOnce attempts the DLL load a single time; the result is reused
+	// by every subsequent check.
+	availableOnce sync.Once
+	availableErr  error
+)
+
+// loadError returns the cached result of loading computenetwork.dll.
+func loadError() error {
+	availableOnce.Do(func() {
+		availableErr = modcomputenetwork.Load()
+	})
+	return availableErr
+}
+
+// Available reports whether the HCN API is present on this host. It is a
+// cheap check: the DLL load is only attempted on the first call.
+func Available() bool {
+	return loadError() == nil
+}
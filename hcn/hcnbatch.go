@@ -0,0 +1,91 @@
+//go:build windows
+
+package hcn
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/Microsoft/go-winio/pkg/guid"
+	"github.com/sirupsen/logrus"
+)
+
+// defaultParallelism bounds the number of concurrent per-object calls issued
+// by helpers that fan out over an enumeration, so a large object count does
+// not overwhelm the HNS service.
+const defaultParallelism = 8
+
+// BatchFailure records one object that a fan-out operation could not process.
+type BatchFailure[T any] struct {
+	Item T
+	Err  error
+}
+
+// BatchResult reports, per object, the outcome of a fan-out operation.
+type BatchResult[T any] struct {
+	// Succeeded lists the objects that were processed.
+	Succeeded []T
+	// Failed lists the objects that were not, each with its error.
+	Failed []BatchFailure[T]
+}
+
+// Err returns nil when every object succeeded, and otherwise the per-object
+// errors joined together.
+func (r BatchResult[T]) Err() error {
+	errs := make([]error, 0, len(r.Failed))
+	for _, failure := range r.Failed {
+		errs = append(errs, failure.Err)
+	}
+	return errors.Join(errs...)
+}
+
+// DeleteAll deletes every object in ids of the given type, continuing past
+// failures so one stuck object does not block removing the rest during node
+// cleanup. The returned error joins one entry per object that could not be
+// deleted, each naming the GUID; nil means everything was deleted.
+func DeleteAll(ids []guid.GUID, objType ObjectType) error {
+	logrus.Debugf("hcn::DeleteAll type=%s count=%d", objType, len(ids))
+
+	var deleteFn func(string) error
+	switch objType {
+	case ObjectTypeNetwork:
+		deleteFn = deleteNetwork
+	case ObjectTypeEndpoint:
+		deleteFn = deleteEndpoint
+	case ObjectTypeNamespace:
+		deleteFn = deleteNamespace
+	case ObjectTypeLoadBalancer:
+		deleteFn = deleteLoadBalancer
+	case ObjectTypeRoute:
+		deleteFn = deleteRoute
+	default:
+		return fmt.Errorf("unknown object type %q", objType)
+	}
+
+	var errs []error
+	for _, id := range ids {
+		if err := deleteFn(id.String()); err != nil {
+			errs = append(errs, fmt.Errorf("%s %s: %w", objType, id, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// runBounded invokes fn for every index in [0, n), with at most
+// defaultParallelism invocations in flight at once, and waits for all of them
+// to finish.
+func runBounded(n int, fn func(i int)) {
+	sem := make(chan struct{}, defaultParallelism)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			fn(i)
+		}(i)
+	}
+	wg.Wait()
+}
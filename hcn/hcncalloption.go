@@ -0,0 +1,95 @@
+//go:build windows
+
+package hcn
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+)
+
+// CallOption adjusts a single create or modify call without touching package
+// or object state, so concurrent callers with different needs do not race on
+// a shared setting.
+type CallOption func(*callOptions)
+
+type callOptions struct {
+	schemaVersion *SchemaVersion
+}
+
+// WithSchemaVersion overrides the schema version for the one call it is
+// passed to. The version is validated against SupportedSchemaVersions when
+// the call runs; other calls keep using whatever version their settings
+// carry.
+func WithSchemaVersion(major, minor uint32) CallOption {
+	return func(o *callOptions) {
+		o.schemaVersion = &SchemaVersion{Major: int(major), Minor: int(minor)}
+	}
+}
+
+// resolveCallOptions folds the options into a callOptions struct.
+func resolveCallOptions(options []CallOption) callOptions {
+	var resolved callOptions
+	for _, option := range options {
+		option(&resolved)
+	}
+	return resolved
+}
+
+// applySchemaVersion writes the override, if any, into sv after validating
+// it against the versions the running service supports.
+func (o *callOptions) applySchemaVersion(sv *SchemaVersion) error {
+	if o.schemaVersion == nil {
+		return nil
+	}
+	supported, err := SupportedSchemaVersions()
+	if err != nil {
+		return err
+	}
+	if !slices.Contains(supported, *o.schemaVersion) {
+		return fmt.Errorf("schema version %d.%d is not supported by the HNS service", o.schemaVersion.Major, o.schemaVersion.Minor)
+	}
+	*sv = *o.schemaVersion
+	return nil
+}
+
+var (
+	schemaVersionsMu     sync.Mutex
+	cachedSchemaVersions []SchemaVersion
+)
+
+// SupportedSchemaVersions returns the schema versions the running HNS
+// service accepts, derived from the service's reported version and API
+// support rather than guessed from the OS build. The result is cached after
+// the first successful query; failures are not cached, so a service that was
+// briefly unreachable does not poison later calls.
+func SupportedSchemaVersions() ([]SchemaVersion, error) {
+	schemaVersionsMu.Lock()
+	defer schemaVersionsMu.Unlock()
+	if cachedSchemaVersions != nil {
+		return slices.Clone(cachedSchemaVersions), nil
+	}
+	features, err := GetCachedSupportedFeatures()
+	if err != nil {
+		return nil, err
+	}
+	globals, err := GetGlobals()
+	if err != nil {
+		return nil, err
+	}
+	var versions []SchemaVersion
+	if features.Api.V1 {
+		versions = append(versions, SchemaVersion{Major: 1, Minor: 0})
+	}
+	if features.Api.V2 {
+		versions = append(versions, SchemaVersion{Major: 2, Minor: 0})
+		if isFeatureSupported(globals.Version, Schema21Version) {
+			versions = append(versions, SchemaVersion{Major: 2, Minor: 1})
+		}
+		if isFeatureSupported(globals.Version, Schema22Version) {
+			versions = append(versions, SchemaVersion{Major: 2, Minor: 2})
+		}
+	}
+	cachedSchemaVersions = versions
+	return slices.Clone(versions), nil
+}
@@ -0,0 +1,64 @@
+//go:build windows
+
+package hcn
+
+import (
+	"math"
+	"sync"
+)
+
+var (
+	// capabilitiesOnce probes the service a single time; the result is reused by
+	// every subsequent Capabilities call.
+	capabilitiesOnce sync.Once
+	capabilitiesErr  error
+	capabilities     *HNSCapabilities
+)
+
+// HNSCapabilities bundles the HNS service version with the support matrix for
+// the features callers most commonly branch on, so a single query can replace
+// repeated per-feature version and procedure checks.
+type HNSCapabilities struct {
+	// Version is the HNS service version.
+	Version Version
+	// DSR reports support for Direct Server Return loadbalancing.
+	DSR bool
+	// IOV reports support for IOV endpoint offload policies.
+	IOV bool
+	// IPv6 reports support for IPv6 dual stack.
+	IPv6 bool
+	// SDNRoutes reports support for the HostComputeRoute (SDN route) API.
+	SDNRoutes bool
+	// L4Proxy reports support for the network L4Proxy policy.
+	L4Proxy bool
+}
+
+// IovVersion is the HNS version that added IOV endpoint offload policies.
+var IovVersion = VersionRanges{VersionRange{MinVersion: Version{Major: 10, Minor: 0}, MaxVersion: Version{Major: math.MaxInt32, Minor: math.MaxInt32}}}
+
+// Capabilities returns the HNS service version and the feature support matrix.
+// The service is only probed on the first call; later calls return the cached
+// result, so it is cheap to consult per-operation.
+func Capabilities() (*HNSCapabilities, error) {
+	capabilitiesOnce.Do(func() {
+		capabilities, capabilitiesErr = getCapabilities()
+	})
+	return capabilities, capabilitiesErr
+}
+
+func getCapabilities() (*HNSCapabilities, error) {
+	globals, err := GetGlobals()
+	if err != nil {
+		return nil, err
+	}
+	return &HNSCapabilities{
+		Version: globals.Version,
+		DSR:     isFeatureSupported(globals.Version, DSRVersion),
+		IOV:     isFeatureSupported(globals.Version, IovVersion),
+		IPv6:    isFeatureSupported(globals.Version, IPv6DualStackVersion),
+		// The SDN route API ships as its own set of exports, so probe the
+		// procedure rather than a version range.
+		SDNRoutes: procHcnCreateSdnRoute.Find() == nil,
+		L4Proxy:   isFeatureSupported(globals.Version, L4ProxyPolicyVersion),
+	}, nil
+}
@@ -0,0 +1,68 @@
+//go:build windows
+
+package hcn
+
+import (
+	"encoding/json"
+	"slices"
+)
+
+// TypedEndpointPolicy pairs a policy type with its decoded setting, as
+// produced by TypedPolicies.
+type TypedEndpointPolicy struct {
+	Type    EndpointPolicyType
+	Setting interface{}
+}
+
+// TypedPolicies decodes each policy into its typed setting. Policies of
+// unknown type keep their raw JSON settings.
+func TypedPolicies(policies []EndpointPolicy) []TypedEndpointPolicy {
+	typed := make([]TypedEndpointPolicy, 0, len(policies))
+	for _, policy := range policies {
+		if setting, ok := decodeEndpointPolicySetting(policy); ok {
+			typed = append(typed, TypedEndpointPolicy{Type: policy.Type, Setting: setting})
+		} else {
+			typed = append(typed, TypedEndpointPolicy{Type: policy.Type, Setting: policy.Settings})
+		}
+	}
+	return typed
+}
+
+// orderSensitivePolicyTypes are the policy types whose relative order HNS
+// evaluates; for all other types order carries no meaning.
+var orderSensitivePolicyTypes = []EndpointPolicyType{ACL, TierAcl}
+
+// PoliciesEqual reports whether two policy sets are equivalent: for
+// order-sensitive types (ACL tiers and rules) the sequences must match in
+// order, while all other types are compared as multisets. Comparing the
+// decoded typed settings means fields HNS auto-populates on query, which the
+// typed structs do not carry, are ignored. Controllers can use this to skip
+// modifies that would be no-ops.
+func PoliciesEqual(a, b []TypedEndpointPolicy) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	aOrdered, aUnordered := canonicalPolicies(a)
+	bOrdered, bUnordered := canonicalPolicies(b)
+	return slices.Equal(aOrdered, bOrdered) && slices.Equal(aUnordered, bUnordered)
+}
+
+// canonicalPolicies renders policies into comparable strings, split into the
+// order-sensitive sequence (in input order) and the order-insensitive set
+// (sorted).
+func canonicalPolicies(policies []TypedEndpointPolicy) (ordered []string, unordered []string) {
+	for _, policy := range policies {
+		settings, err := json.Marshal(policy.Setting)
+		if err != nil {
+			settings = []byte(err.Error())
+		}
+		canonical := string(policy.Type) + ":" + string(settings)
+		if slices.Contains(orderSensitivePolicyTypes, policy.Type) {
+			ordered = append(ordered, canonical)
+		} else {
+			unordered = append(unordered, canonical)
+		}
+	}
+	slices.Sort(unordered)
+	return ordered, unordered
+}
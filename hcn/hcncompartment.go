@@ -0,0 +1,114 @@
+//go:build windows
+
+package hcn
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// InCompartment runs fn with the calling OS thread switched into the given
+// network compartment, and restores the previous compartment afterwards even
+// when fn panics. The raw SetCurrentThreadCompartmentId mutates OS-thread
+// state that outlives the goroutine's time on that thread, so using it
+// without locking and restoring leaks the compartment onto whatever
+// goroutine the scheduler runs there next; InCompartment does the
+// LockOSThread/save/set/restore/unlock dance so callers cannot get it wrong.
+//
+// If the original compartment cannot be restored, the thread is left locked:
+// the runtime then discards it when the goroutine exits instead of returning
+// a thread stuck in the wrong compartment to the pool.
+func InCompartment(id uint32, fn func() error) error {
+	runtime.LockOSThread()
+
+	previous := GetCurrentThreadCompartmentId()
+	logrus.Debugf("hcn::InCompartment id=%d previous=%d", id, previous)
+	if err := SetCurrentThreadCompartmentId(id); err != nil {
+		runtime.UnlockOSThread()
+		return fmt.Errorf("enter compartment %d: %w", id, err)
+	}
+	defer func() {
+		if err := SetCurrentThreadCompartmentId(previous); err != nil {
+			logrus.Errorf("hcn::InCompartment failed to restore compartment %d, abandoning thread: %s", previous, err)
+			return
+		}
+		runtime.UnlockOSThread()
+	}()
+	return fn()
+}
+
+const (
+	// unspecifiedCompartmentID is never a valid compartment.
+	unspecifiedCompartmentID uint32 = 0
+	// defaultCompartmentID is the host's default compartment, which always
+	// exists and is never handed out by the allocator.
+	defaultCompartmentID uint32 = 1
+	// maxAllocatableCompartmentID caps the IDs the allocator hands out. The
+	// OS refuses to create compartments long before the uint32 space runs
+	// out, so a bounded scan keeps Allocate cheap while still reporting
+	// exhaustion as an error rather than looping forever.
+	maxAllocatableCompartmentID uint32 = 4096
+)
+
+// ErrCompartmentsExhausted is returned by Allocate when every compartment ID
+// up to the allocator's limit is in use.
+var ErrCompartmentsExhausted = errors.New("no free network compartment IDs")
+
+// CompartmentAllocator hands out network compartment IDs that are not in use,
+// replacing ad hoc bookkeeping around SetCurrentThreadCompartmentId. It is
+// seeded from the compartments existing namespaces occupy and is safe for
+// concurrent use. It tracks only this process's view: compartments created
+// outside HNS after seeding are not visible to it.
+type CompartmentAllocator struct {
+	mu   sync.Mutex
+	used map[uint32]bool
+}
+
+// NewCompartmentAllocator returns an allocator seeded with the default
+// compartment and every compartment an existing namespace is attached to.
+func NewCompartmentAllocator() (*CompartmentAllocator, error) {
+	namespaces, err := ListNamespaces()
+	if err != nil {
+		return nil, fmt.Errorf("seed compartment allocator: %w", err)
+	}
+	used := map[uint32]bool{defaultCompartmentID: true}
+	for _, namespace := range namespaces {
+		if namespace.NamespaceId != unspecifiedCompartmentID {
+			used[namespace.NamespaceId] = true
+		}
+	}
+	return &CompartmentAllocator{used: used}, nil
+}
+
+// Allocate reserves and returns an unused compartment ID, or
+// ErrCompartmentsExhausted when none is free below the allocator's limit.
+func (a *CompartmentAllocator) Allocate() (uint32, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for id := defaultCompartmentID + 1; id <= maxAllocatableCompartmentID; id++ {
+		if !a.used[id] {
+			a.used[id] = true
+			logrus.Debugf("hcn::CompartmentAllocator::Allocate id=%d", id)
+			return id, nil
+		}
+	}
+	return unspecifiedCompartmentID, ErrCompartmentsExhausted
+}
+
+// Release returns a previously allocated ID to the pool. Releasing an ID that
+// is not allocated, or the default compartment, is a no-op.
+func (a *CompartmentAllocator) Release(id uint32) {
+	if id == unspecifiedCompartmentID || id == defaultCompartmentID {
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.used[id] {
+		logrus.Debugf("hcn::CompartmentAllocator::Release id=%d", id)
+		delete(a.used, id)
+	}
+}
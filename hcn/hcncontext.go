@@ -0,0 +1,163 @@
+//go:build windows
+
+package hcn
+
+import (
+	"context"
+
+	"github.com/sirupsen/logrus"
+)
+
+// awaitContext runs call on its own goroutine and waits for it or for ctx,
+// whichever finishes first. The HCN calls themselves cannot be aborted once
+// they have entered the DLL, so when ctx wins the race the call is left to
+// finish in the background and cleanup, if non-nil, is invoked with its
+// result — this is how a cancelled create still deletes the object HCN
+// built, instead of leaking it. The handle the wrapper opened is always
+// closed by the wrapper itself regardless of cancellation.
+func awaitContext[T any](ctx context.Context, call func() (T, error), cleanup func(T)) (T, error) {
+	type outcome struct {
+		value T
+		err   error
+	}
+	done := make(chan outcome, 1)
+	go func() {
+		value, err := call()
+		done <- outcome{value: value, err: err}
+	}()
+	select {
+	case out := <-done:
+		return out.value, out.err
+	case <-ctx.Done():
+		go func() {
+			out := <-done
+			if out.err == nil && cleanup != nil {
+				cleanup(out.value)
+			}
+		}()
+		var zero T
+		return zero, ctx.Err()
+	}
+}
+
+// awaitContextErr is awaitContext for calls that only return an error, such
+// as deletes and modifies, where there is nothing to clean up if
+// cancellation wins.
+func awaitContextErr(ctx context.Context, call func() error) error {
+	_, err := awaitContext(ctx, func() (struct{}, error) {
+		return struct{}{}, call()
+	}, nil)
+	return err
+}
+
+// deleteAbandoned deletes an object whose create finished after the caller's
+// context was already cancelled, logging rather than returning the outcome
+// since no caller is left to receive it.
+func deleteAbandoned(objType ObjectType, id string, deleteFn func() error) {
+	if err := deleteFn(); err != nil {
+		logrus.Errorf("hcn::awaitContext failed to delete abandoned %s %s: %s", objType, id, err)
+		return
+	}
+	logrus.Debugf("hcn::awaitContext deleted abandoned %s %s", objType, id)
+}
+
+// CreateContext is Create bounded by ctx: if ctx is cancelled before the HCN
+// call returns, ctx's error is returned immediately and the endpoint, if the
+// call later succeeds anyway, is deleted in the background so it does not
+// leak.
+func (endpoint *HostComputeEndpoint) CreateContext(ctx context.Context, options ...CallOption) (*HostComputeEndpoint, error) {
+	return awaitContext(ctx, func() (*HostComputeEndpoint, error) {
+		return endpoint.Create(options...)
+	}, func(created *HostComputeEndpoint) {
+		deleteAbandoned(ObjectTypeEndpoint, created.Id, created.Delete)
+	})
+}
+
+// DeleteContext is Delete bounded by ctx. A cancelled context abandons the
+// wait, not the delete: the HCN call still runs to completion.
+func (endpoint *HostComputeEndpoint) DeleteContext(ctx context.Context) error {
+	return awaitContextErr(ctx, endpoint.Delete)
+}
+
+// CreateContext is Create bounded by ctx, deleting the network in the
+// background if the call succeeds after cancellation.
+func (network *HostComputeNetwork) CreateContext(ctx context.Context, options ...CallOption) (*HostComputeNetwork, error) {
+	return awaitContext(ctx, func() (*HostComputeNetwork, error) {
+		return network.Create(options...)
+	}, func(created *HostComputeNetwork) {
+		deleteAbandoned(ObjectTypeNetwork, created.Id, created.Delete)
+	})
+}
+
+// DeleteContext is Delete bounded by ctx. A cancelled context abandons the
+// wait, not the delete: the HCN call still runs to completion.
+func (network *HostComputeNetwork) DeleteContext(ctx context.Context) error {
+	return awaitContextErr(ctx, network.Delete)
+}
+
+// CreateContext is Create bounded by ctx, deleting the namespace in the
+// background if the call succeeds after cancellation.
+func (namespace *HostComputeNamespace) CreateContext(ctx context.Context, options ...CallOption) (*HostComputeNamespace, error) {
+	return awaitContext(ctx, func() (*HostComputeNamespace, error) {
+		return namespace.Create(options...)
+	}, func(created *HostComputeNamespace) {
+		deleteAbandoned(ObjectTypeNamespace, created.Id, created.Delete)
+	})
+}
+
+// DeleteContext is Delete bounded by ctx. A cancelled context abandons the
+// wait, not the delete: the HCN call still runs to completion.
+func (namespace *HostComputeNamespace) DeleteContext(ctx context.Context) error {
+	return awaitContextErr(ctx, namespace.Delete)
+}
+
+// CreateContext is Create bounded by ctx, deleting the load balancer in the
+// background if the call succeeds after cancellation.
+func (loadBalancer *HostComputeLoadBalancer) CreateContext(ctx context.Context, options ...CallOption) (*HostComputeLoadBalancer, error) {
+	return awaitContext(ctx, func() (*HostComputeLoadBalancer, error) {
+		return loadBalancer.Create(options...)
+	}, func(created *HostComputeLoadBalancer) {
+		deleteAbandoned(ObjectTypeLoadBalancer, created.Id, created.Delete)
+	})
+}
+
+// DeleteContext is Delete bounded by ctx. A cancelled context abandons the
+// wait, not the delete: the HCN call still runs to completion.
+func (loadBalancer *HostComputeLoadBalancer) DeleteContext(ctx context.Context) error {
+	return awaitContextErr(ctx, loadBalancer.Delete)
+}
+
+// CreateContext is Create bounded by ctx, deleting the route in the
+// background if the call succeeds after cancellation.
+func (route *HostComputeRoute) CreateContext(ctx context.Context, options ...CallOption) (*HostComputeRoute, error) {
+	return awaitContext(ctx, func() (*HostComputeRoute, error) {
+		return route.Create(options...)
+	}, func(created *HostComputeRoute) {
+		deleteAbandoned(ObjectTypeRoute, created.ID, created.Delete)
+	})
+}
+
+// DeleteContext is Delete bounded by ctx. A cancelled context abandons the
+// wait, not the delete: the HCN call still runs to completion.
+func (route *HostComputeRoute) DeleteContext(ctx context.Context) error {
+	return awaitContextErr(ctx, route.Delete)
+}
+
+// ModifyEndpointSettingsContext is ModifyEndpointSettings bounded by ctx. A
+// cancelled context abandons the wait, not the modify: the HCN call still
+// runs to completion, so the endpoint may end up modified even when ctx's
+// error is returned.
+func ModifyEndpointSettingsContext(ctx context.Context, endpointID string, request *ModifyEndpointSettingRequest) error {
+	return awaitContextErr(ctx, func() error {
+		return ModifyEndpointSettings(endpointID, request)
+	})
+}
+
+// ModifyNamespaceSettingsContext is ModifyNamespaceSettings bounded by ctx,
+// with the same caveat as ModifyEndpointSettingsContext: the modify itself is
+// not aborted by cancellation.
+func ModifyNamespaceSettingsContext(ctx context.Context, namespaceID string, request *ModifyNamespaceSettingRequest) error {
+	return awaitContextErr(ctx, func() error {
+		return ModifyNamespaceSettings(namespaceID, request)
+	})
+}
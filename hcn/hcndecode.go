@@ -0,0 +1,33 @@
+//go:build windows
+
+package hcn
+
+import (
+	"syscall"
+	"unsafe"
+
+	"github.com/Microsoft/hcsshim/internal/interop"
+)
+
+// The decode and free steps are variables so tests can substitute fakes and
+// verify the buffer is released even when decoding fails.
+var (
+	decodeUTF16String = func(buffer *uint16) (string, error) {
+		return syscall.UTF16ToString((*[1 << 29]uint16)(unsafe.Pointer(buffer))[:]), nil
+	}
+	freeCoTaskMem = func(buffer *uint16) {
+		interop.CoTaskMemFree(unsafe.Pointer(buffer))
+	}
+)
+
+// decodeAndFree decodes a UTF-16 buffer the HCN API allocated into a Go
+// string and frees it, with the free guaranteed by defer even when decoding
+// fails — the COM allocation must never outlive the call that received it.
+// A nil buffer decodes to "" and frees nothing.
+func decodeAndFree(buffer *uint16) (string, error) {
+	if buffer == nil {
+		return "", nil
+	}
+	defer freeCoTaskMem(buffer)
+	return decodeUTF16String(buffer)
+}
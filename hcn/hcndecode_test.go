@@ -0,0 +1,53 @@
+//go:build windows && integration
+// +build windows,integration
+
+package hcn
+
+import (
+	"errors"
+	"syscall"
+	"testing"
+)
+
+func TestDecodeAndFreeAlwaysFrees(t *testing.T) {
+	origDecode, origFree := decodeUTF16String, freeCoTaskMem
+	defer func() {
+		decodeUTF16String, freeCoTaskMem = origDecode, origFree
+	}()
+
+	freed := 0
+	freeCoTaskMem = func(*uint16) { freed++ }
+
+	buffer, err := syscall.UTF16FromString("hello")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := decodeAndFree(&buffer[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "hello" {
+		t.Errorf("decoded %q, want %q", got, "hello")
+	}
+	if freed != 1 {
+		t.Fatalf("expected 1 free after successful decode, got %d", freed)
+	}
+
+	decodeUTF16String = func(*uint16) (string, error) {
+		return "", errors.New("malformed utf-16")
+	}
+	if _, err := decodeAndFree(&buffer[0]); err == nil {
+		t.Fatal("expected decode failure to be reported")
+	}
+	if freed != 2 {
+		t.Fatalf("expected the buffer to be freed on decode error, got %d frees", freed)
+	}
+
+	if got, err := decodeAndFree(nil); err != nil || got != "" {
+		t.Fatalf("nil buffer should decode to empty string, got %q, %v", got, err)
+	}
+	if freed != 2 {
+		t.Fatalf("nil buffer must not be freed, got %d frees", freed)
+	}
+}
@@ -0,0 +1,92 @@
+//go:build windows
+
+package hcn
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Describe renders a multi-line, human-readable description of the endpoint
+// for support bundles: GUID, network, addresses, MAC, DNS, and each policy
+// decoded into its typed setting. The endpoint is re-queried from the service
+// first so the dump reflects current state; if the query fails, the in-memory
+// copy is rendered instead. The output is stable for a given configuration
+// but is not a machine-readable format.
+func (endpoint *HostComputeEndpoint) Describe() string {
+	if current, err := GetEndpointByID(endpoint.Id); err == nil {
+		endpoint = current
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Endpoint %s (%q)\n", endpoint.Id, endpoint.Name)
+	fmt.Fprintf(&b, "  Network:   %s\n", endpoint.HostComputeNetwork)
+	if endpoint.HostComputeNamespace != "" {
+		fmt.Fprintf(&b, "  Namespace: %s\n", endpoint.HostComputeNamespace)
+	}
+	fmt.Fprintf(&b, "  MAC:       %s\n", endpoint.MacAddress)
+	for _, ipConfig := range endpoint.IpConfigurations {
+		fmt.Fprintf(&b, "  Address:   %s/%d\n", ipConfig.IpAddress, ipConfig.PrefixLength)
+	}
+	for _, route := range endpoint.Routes {
+		fmt.Fprintf(&b, "  Route:     %s via %s\n", route.DestinationPrefix, route.NextHop)
+	}
+	if len(endpoint.Dns.ServerList) > 0 || endpoint.Dns.Domain != "" {
+		fmt.Fprintf(&b, "  DNS:       servers=%s domain=%q search=%s\n",
+			strings.Join(endpoint.Dns.ServerList, ","), endpoint.Dns.Domain, strings.Join(endpoint.Dns.Search, ","))
+	}
+	for _, policy := range endpoint.Policies {
+		if setting, ok := decodeEndpointPolicySetting(policy); ok {
+			fmt.Fprintf(&b, "  Policy:    %s %+v\n", policy.Type, setting)
+		} else {
+			fmt.Fprintf(&b, "  Policy:    %s %s\n", policy.Type, string(policy.Settings))
+		}
+	}
+	return b.String()
+}
+
+// decodeEndpointPolicySetting unmarshals a policy's settings into the typed
+// setting struct for its policy type. It reports false for unknown types or
+// settings that do not decode, so callers can fall back to the raw JSON.
+func decodeEndpointPolicySetting(policy EndpointPolicy) (interface{}, bool) {
+	var setting interface{}
+	switch policy.Type {
+	case PortMapping:
+		setting = &PortMappingPolicySetting{}
+	case ACL:
+		setting = &AclPolicySetting{}
+	case QOS:
+		setting = &QosPolicySetting{}
+	case OutBoundNAT:
+		setting = &OutboundNatPolicySetting{}
+	case SDNRoute:
+		setting = &SDNRoutePolicySetting{}
+	case L4Proxy:
+		setting = &L4ProxyPolicySetting{}
+	case L4WFPPROXY:
+		setting = &L4WfpProxyPolicySetting{}
+	case PortName:
+		setting = &PortnameEndpointPolicySetting{}
+	case EncapOverhead:
+		setting = &EncapOverheadEndpointPolicySetting{}
+	case IOV:
+		setting = &IovPolicySetting{}
+	case NetworkProviderAddress:
+		setting = &ProviderAddressEndpointPolicySetting{}
+	case NetworkInterfaceConstraint:
+		setting = &InterfaceConstraintPolicySetting{}
+	case TierAcl:
+		setting = &TierAclPolicySetting{}
+	case RoutingPriority:
+		setting = &RoutingPriorityEndpointPolicySetting{}
+	case Mtu:
+		setting = &MtuPolicySetting{}
+	default:
+		return nil, false
+	}
+	if err := json.Unmarshal(policy.Settings, setting); err != nil {
+		return nil, false
+	}
+	return setting, true
+}
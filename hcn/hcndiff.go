@@ -0,0 +1,58 @@
+//go:build windows
+
+package hcn
+
+import (
+	"encoding/json"
+	"reflect"
+)
+
+// DiffEndpoint computes the smallest set of modify requests that brings the
+// live endpoint state in current to the state in desired. One request is
+// emitted per resource type that actually differs — policies, DNS, or IP
+// configuration — so a single-field change does not trigger a full
+// replacement. The second return reports whether any change is needed at all.
+// Policies are compared with PoliciesEqual, so reordering policies whose
+// order HNS ignores produces no request. The comparison is pure logic; the
+// caller applies the requests via ModifyEndpointSettings.
+func DiffEndpoint(current, desired *HostComputeEndpoint) ([]*ModifyEndpointSettingRequest, bool, error) {
+	var requests []*ModifyEndpointSettingRequest
+
+	if !PoliciesEqual(TypedPolicies(current.Policies), TypedPolicies(desired.Policies)) {
+		settings, err := json.Marshal(PolicyEndpointRequest{Policies: desired.Policies})
+		if err != nil {
+			return nil, false, err
+		}
+		requests = append(requests, &ModifyEndpointSettingRequest{
+			ResourceType: EndpointResourceTypePolicy,
+			RequestType:  RequestTypeUpdate,
+			Settings:     settings,
+		})
+	}
+
+	if !reflect.DeepEqual(current.Dns, desired.Dns) {
+		settings, err := json.Marshal(desired.Dns)
+		if err != nil {
+			return nil, false, err
+		}
+		requests = append(requests, &ModifyEndpointSettingRequest{
+			ResourceType: EndpointResourceTypeDNS,
+			RequestType:  RequestTypeUpdate,
+			Settings:     settings,
+		})
+	}
+
+	if !reflect.DeepEqual(current.IpConfigurations, desired.IpConfigurations) {
+		settings, err := json.Marshal(desired.IpConfigurations)
+		if err != nil {
+			return nil, false, err
+		}
+		requests = append(requests, &ModifyEndpointSettingRequest{
+			ResourceType: EndpointResourceTypeIPConfig,
+			RequestType:  RequestTypeUpdate,
+			Settings:     settings,
+		})
+	}
+
+	return requests, len(requests) > 0, nil
+}
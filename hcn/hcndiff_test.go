@@ -0,0 +1,60 @@
+//go:build windows && integration
+// +build windows,integration
+
+package hcn
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestDiffEndpointNoChange(t *testing.T) {
+	policy, err := json.Marshal(PortnameEndpointPolicySetting{Name: "port"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	endpoint := &HostComputeEndpoint{
+		Policies: []EndpointPolicy{{Type: PortName, Settings: policy}},
+		Dns:      Dns{ServerList: []string{"10.0.0.2"}},
+		IpConfigurations: []IpConfig{
+			{IpAddress: "10.0.0.10", PrefixLength: 24},
+		},
+	}
+	desired := *endpoint
+
+	requests, changed, err := DiffEndpoint(endpoint, &desired)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if changed || len(requests) != 0 {
+		t.Fatalf("expected no diff, got %d request(s)", len(requests))
+	}
+}
+
+func TestDiffEndpointSingleFieldChange(t *testing.T) {
+	current := &HostComputeEndpoint{
+		Dns: Dns{ServerList: []string{"10.0.0.2"}},
+		IpConfigurations: []IpConfig{
+			{IpAddress: "10.0.0.10", PrefixLength: 24},
+		},
+	}
+	desired := *current
+	desired.Dns = Dns{ServerList: []string{"10.0.0.2", "10.0.0.3"}}
+
+	requests, changed, err := DiffEndpoint(current, &desired)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !changed {
+		t.Fatal("expected a diff")
+	}
+	if len(requests) != 1 {
+		t.Fatalf("expected one request, got %d", len(requests))
+	}
+	if requests[0].ResourceType != EndpointResourceTypeDNS {
+		t.Fatalf("expected a DNS request, got %s", requests[0].ResourceType)
+	}
+	if requests[0].RequestType != RequestTypeUpdate {
+		t.Fatalf("expected an update, got %s", requests[0].RequestType)
+	}
+}
@@ -3,11 +3,18 @@
 package hcn
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"slices"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/Microsoft/go-winio/pkg/guid"
-	"github.com/Microsoft/hcsshim/internal/interop"
 	"github.com/sirupsen/logrus"
 )
 
@@ -15,6 +22,11 @@ import (
 type IpConfig struct {
 	IpAddress    string `json:",omitempty"`
 	PrefixLength uint8  `json:",omitempty"`
+	// AllowDynamicFallback opts this static address into dynamic reassignment:
+	// when creation fails because the address conflicts,
+	// CreateWithDynamicFallback retries with dynamic IPAM. It is not part of
+	// the HNS schema and is never marshaled.
+	AllowDynamicFallback bool `json:"-"`
 }
 
 // EndpointFlags are special settings on an endpoint.
@@ -25,8 +37,48 @@ var (
 	EndpointFlagsNone EndpointFlags
 	// EndpointFlagsRemoteEndpoint means that an endpoint is on another host.
 	EndpointFlagsRemoteEndpoint EndpointFlags = 1
+	// EndpointFlagsDisableICC blocks inter-container connectivity: the
+	// endpoint can reach the outside world but not other endpoints on the
+	// same network.
+	EndpointFlagsDisableICC EndpointFlags = 2
+	// EndpointFlagsEnableLowMetric gives the endpoint's interface a low
+	// routing preference (high metric), so multi-homed containers prefer
+	// their other interfaces.
+	EndpointFlagsEnableLowMetric EndpointFlags = 4
+	// EndpointFlagsIsolateVSwitch isolates the endpoint's vSwitch port, so
+	// traffic is only forwarded to and from the external network.
+	EndpointFlagsIsolateVSwitch EndpointFlags = 8
+	// EndpointFlagsConfidential marks the endpoint for a confidential
+	// (hardware isolated) workload. Requires Windows Server 2025 (HNS 15.4);
+	// see WithConfidential.
+	EndpointFlagsConfidential EndpointFlags = 16
 )
 
+// knownEndpointFlags is the set of flags WithEndpointFlags accepts.
+var knownEndpointFlags = EndpointFlagsRemoteEndpoint |
+	EndpointFlagsDisableICC |
+	EndpointFlagsEnableLowMetric |
+	EndpointFlagsIsolateVSwitch |
+	EndpointFlagsConfidential
+
+// validate rejects unknown bits and combinations HNS does not support.
+func (flags EndpointFlags) validate() error {
+	if unknown := flags &^ knownEndpointFlags; unknown != 0 {
+		return fmt.Errorf("unknown endpoint flags 0x%x", uint32(unknown))
+	}
+	// A remote endpoint has no local interface, so the local-interface
+	// behaviors cannot apply to it.
+	if flags&EndpointFlagsRemoteEndpoint != 0 &&
+		flags&(EndpointFlagsEnableLowMetric|EndpointFlagsIsolateVSwitch) != 0 {
+		return errors.New("remote endpoints cannot combine local interface flags")
+	}
+	// A confidential endpoint's isolation is anchored to the local host.
+	if flags&EndpointFlagsConfidential != 0 && flags&EndpointFlagsRemoteEndpoint != 0 {
+		return errors.New("remote endpoints cannot be confidential")
+	}
+	return nil
+}
+
 // HostComputeEndpoint represents a network endpoint
 type HostComputeEndpoint struct {
 	Id                   string           `json:"ID,omitempty"`
@@ -39,8 +91,29 @@ type HostComputeEndpoint struct {
 	Routes               []Route          `json:",omitempty"`
 	MacAddress           string           `json:",omitempty"`
 	Flags                EndpointFlags    `json:",omitempty"`
+	SwitchPortId         string           `json:",omitempty"` // GUID
 	Health               Health           `json:",omitempty"`
 	SchemaVersion        SchemaVersion    `json:",omitempty"`
+	// PostCreatePolicies are applied by Create immediately after the endpoint
+	// exists, closing the window an endpoint would otherwise spend without
+	// its standard policy set. They are for policies HNS rejects in the
+	// create document and are not part of the HNS schema; see Create for the
+	// rollback semantics.
+	PostCreatePolicies []EndpointPolicy `json:"-"`
+	// SkipDefaultGateway keeps Create from installing a default route on the
+	// endpoint, for sidecar and appliance endpoints that must route all
+	// traffic explicitly. Any default route in Routes is dropped from the
+	// create document, and creation fails if HNS installed one anyway or if
+	// the endpoint would be left with no connectivity configuration at all.
+	// It is not part of the HNS schema.
+	SkipDefaultGateway bool `json:"-"`
+}
+
+// isDefaultRoute reports whether the destination prefix matches all
+// addresses, i.e. is an IPv4 or IPv6 default route.
+func isDefaultRoute(destinationPrefix string) bool {
+	prefix, err := netip.ParsePrefix(destinationPrefix)
+	return err == nil && prefix.Bits() == 0
 }
 
 // EndpointResourceType are the two different Endpoint settings resources.
@@ -49,8 +122,15 @@ type EndpointResourceType string
 var (
 	// EndpointResourceTypePolicy is for Endpoint Policies. Ex: ACL, NAT
 	EndpointResourceTypePolicy EndpointResourceType = "Policy"
+	// EndpointResourceTypeDNS is for Endpoint DNS settings.
+	EndpointResourceTypeDNS EndpointResourceType = "DNS"
 	// EndpointResourceTypePort is for Endpoint Port settings.
 	EndpointResourceTypePort EndpointResourceType = "Port"
+	// EndpointResourceTypeIPConfig is for Endpoint IP configurations.
+	EndpointResourceTypeIPConfig EndpointResourceType = "IPConfiguration"
+	// EndpointResourceTypeCompartment is for sharing an Endpoint across
+	// network compartments.
+	EndpointResourceTypeCompartment EndpointResourceType = "Compartment"
 )
 
 // ModifyEndpointSettingRequest is the structure used to send request to modify an endpoint.
@@ -72,26 +152,65 @@ type PolicyEndpointRequest struct {
 	Policies []EndpointPolicy `json:",omitempty"`
 }
 
+func queryEndpointProperties(endpointGUID guid.GUID, query string) (string, error) {
+	defer acquireSyscallSlot()()
+	// Open endpoint.
+	var (
+		endpointHandle   hcnEndpoint
+		resultBuffer     *uint16
+		propertiesBuffer *uint16
+	)
+	callStart := traceStart()
+	hr := hcnOpenEndpoint(&endpointGUID, &endpointHandle, &resultBuffer)
+	if err := traceEnd("hcnOpenEndpoint", callStart, checkForErrors("hcnOpenEndpoint", hr, &resultBuffer)); err != nil {
+		return "", err
+	}
+	// Query endpoint.
+	callStart = traceStart()
+	hr = hcnQueryEndpointProperties(endpointHandle, query, &propertiesBuffer, &resultBuffer)
+	if err := traceEnd("hcnQueryEndpointProperties", callStart, checkForErrors("hcnQueryEndpointProperties", hr, &resultBuffer)); err != nil {
+		return "", err
+	}
+	properties, err := decodeAndFree(propertiesBuffer)
+	if err != nil {
+		return "", err
+	}
+	// Close endpoint.
+	callStart = traceStart()
+	hr = hcnCloseEndpoint(endpointHandle)
+	if err := traceEnd("hcnCloseEndpoint", callStart, checkForErrors("hcnCloseEndpoint", hr, nil)); err != nil {
+		return "", err
+	}
+	return properties, nil
+}
+
 func getEndpoint(endpointGUID guid.GUID, query string) (*HostComputeEndpoint, error) {
+	defer acquireSyscallSlot()()
 	// Open endpoint.
 	var (
 		endpointHandle   hcnEndpoint
 		resultBuffer     *uint16
 		propertiesBuffer *uint16
 	)
+	callStart := traceStart()
 	hr := hcnOpenEndpoint(&endpointGUID, &endpointHandle, &resultBuffer)
-	if err := checkForErrors("hcnOpenEndpoint", hr, resultBuffer); err != nil {
+	if err := traceEnd("hcnOpenEndpoint", callStart, checkForErrors("hcnOpenEndpoint", hr, &resultBuffer)); err != nil {
 		return nil, err
 	}
 	// Query endpoint.
+	callStart = traceStart()
 	hr = hcnQueryEndpointProperties(endpointHandle, query, &propertiesBuffer, &resultBuffer)
-	if err := checkForErrors("hcnQueryEndpointProperties", hr, resultBuffer); err != nil {
+	if err := traceEnd("hcnQueryEndpointProperties", callStart, checkForErrors("hcnQueryEndpointProperties", hr, &resultBuffer)); err != nil {
+		return nil, err
+	}
+	properties, err := decodeAndFree(propertiesBuffer)
+	if err != nil {
 		return nil, err
 	}
-	properties := interop.ConvertAndFreeCoTaskMemString(propertiesBuffer)
 	// Close endpoint.
+	callStart = traceStart()
 	hr = hcnCloseEndpoint(endpointHandle)
-	if err := checkForErrors("hcnCloseEndpoint", hr, nil); err != nil {
+	if err := traceEnd("hcnCloseEndpoint", callStart, checkForErrors("hcnCloseEndpoint", hr, nil)); err != nil {
 		return nil, err
 	}
 	// Convert output to HostComputeEndpoint
@@ -102,20 +221,28 @@ func getEndpoint(endpointGUID guid.GUID, query string) (*HostComputeEndpoint, er
 	return &outputEndpoint, nil
 }
 
-func enumerateEndpoints(query string) ([]HostComputeEndpoint, error) {
+func enumerateEndpointIds(query string) ([]guid.GUID, error) {
+	defer acquireSyscallSlot()()
 	// Enumerate all Endpoint Guids
 	var (
 		resultBuffer   *uint16
 		endpointBuffer *uint16
 	)
+	callStart := traceStart()
 	hr := hcnEnumerateEndpoints(query, &endpointBuffer, &resultBuffer)
-	if err := checkForErrors("hcnEnumerateEndpoints", hr, resultBuffer); err != nil {
+	if err := traceEnd("hcnEnumerateEndpoints", callStart, checkForErrors("hcnEnumerateEndpoints", hr, &resultBuffer)); err != nil {
+		return nil, err
+	}
+
+	endpoints, err := decodeAndFree(endpointBuffer)
+	if err != nil {
 		return nil, err
 	}
+	return decodeGUIDList(endpoints)
+}
 
-	endpoints := interop.ConvertAndFreeCoTaskMemString(endpointBuffer)
-	var endpointIds []guid.GUID
-	err := json.Unmarshal([]byte(endpoints), &endpointIds)
+func enumerateEndpoints(query string) ([]HostComputeEndpoint, error) {
+	endpointIds, err := enumerateEndpointIds(query)
 	if err != nil {
 		return nil, err
 	}
@@ -132,6 +259,10 @@ func enumerateEndpoints(query string) ([]HostComputeEndpoint, error) {
 }
 
 func createEndpoint(networkID string, endpointSettings string) (*HostComputeEndpoint, error) {
+	defer acquireSyscallSlot()()
+	if err := checkSettings("hcnCreateEndpoint", endpointSettings); err != nil {
+		return nil, err
+	}
 	networkGUID, err := guid.FromString(networkID)
 	if err != nil {
 		return nil, errInvalidNetworkID
@@ -139,15 +270,17 @@ func createEndpoint(networkID string, endpointSettings string) (*HostComputeEndp
 	// Open network.
 	var networkHandle hcnNetwork
 	var resultBuffer *uint16
+	callStart := traceStart()
 	hr := hcnOpenNetwork(&networkGUID, &networkHandle, &resultBuffer)
-	if err := checkForErrors("hcnOpenNetwork", hr, resultBuffer); err != nil {
+	if err := traceEnd("hcnOpenNetwork", callStart, checkForErrors("hcnOpenNetwork", hr, &resultBuffer)); err != nil {
 		return nil, err
 	}
 	// Create endpoint.
 	endpointID := guid.GUID{}
 	var endpointHandle hcnEndpoint
+	callStart = traceStart()
 	hr = hcnCreateEndpoint(networkHandle, &endpointID, endpointSettings, &endpointHandle, &resultBuffer)
-	if err := checkForErrors("hcnCreateEndpoint", hr, resultBuffer); err != nil {
+	if err := traceEnd("hcnCreateEndpoint", callStart, checkForErrors("hcnCreateEndpoint", hr, &resultBuffer)); err != nil {
 		return nil, err
 	}
 	// Query endpoint.
@@ -157,19 +290,25 @@ func createEndpoint(networkID string, endpointSettings string) (*HostComputeEndp
 		return nil, err
 	}
 	var propertiesBuffer *uint16
+	callStart = traceStart()
 	hr = hcnQueryEndpointProperties(endpointHandle, string(query), &propertiesBuffer, &resultBuffer)
-	if err := checkForErrors("hcnQueryEndpointProperties", hr, resultBuffer); err != nil {
+	if err := traceEnd("hcnQueryEndpointProperties", callStart, checkForErrors("hcnQueryEndpointProperties", hr, &resultBuffer)); err != nil {
+		return nil, err
+	}
+	properties, err := decodeAndFree(propertiesBuffer)
+	if err != nil {
 		return nil, err
 	}
-	properties := interop.ConvertAndFreeCoTaskMemString(propertiesBuffer)
 	// Close endpoint.
+	callStart = traceStart()
 	hr = hcnCloseEndpoint(endpointHandle)
-	if err := checkForErrors("hcnCloseEndpoint", hr, nil); err != nil {
+	if err := traceEnd("hcnCloseEndpoint", callStart, checkForErrors("hcnCloseEndpoint", hr, nil)); err != nil {
 		return nil, err
 	}
 	// Close network.
+	callStart = traceStart()
 	hr = hcnCloseNetwork(networkHandle)
-	if err := checkForErrors("hcnCloseNetwork", hr, nil); err != nil {
+	if err := traceEnd("hcnCloseNetwork", callStart, checkForErrors("hcnCloseNetwork", hr, nil)); err != nil {
 		return nil, err
 	}
 	// Convert output to HostComputeEndpoint
@@ -181,6 +320,10 @@ func createEndpoint(networkID string, endpointSettings string) (*HostComputeEndp
 }
 
 func modifyEndpoint(endpointID string, settings string) (*HostComputeEndpoint, error) {
+	defer acquireSyscallSlot()()
+	if err := checkSettings("hcnModifyEndpoint", settings); err != nil {
+		return nil, err
+	}
 	endpointGUID, err := guid.FromString(endpointID)
 	if err != nil {
 		return nil, errInvalidEndpointID
@@ -191,13 +334,15 @@ func modifyEndpoint(endpointID string, settings string) (*HostComputeEndpoint, e
 		resultBuffer     *uint16
 		propertiesBuffer *uint16
 	)
+	callStart := traceStart()
 	hr := hcnOpenEndpoint(&endpointGUID, &endpointHandle, &resultBuffer)
-	if err := checkForErrors("hcnOpenEndpoint", hr, resultBuffer); err != nil {
+	if err := traceEnd("hcnOpenEndpoint", callStart, checkForErrors("hcnOpenEndpoint", hr, &resultBuffer)); err != nil {
 		return nil, err
 	}
 	// Modify endpoint
+	callStart = traceStart()
 	hr = hcnModifyEndpoint(endpointHandle, settings, &resultBuffer)
-	if err := checkForErrors("hcnModifyEndpoint", hr, resultBuffer); err != nil {
+	if err := traceEnd("hcnModifyEndpoint", callStart, checkForErrors("hcnModifyEndpoint", hr, &resultBuffer)); err != nil {
 		return nil, err
 	}
 	// Query endpoint.
@@ -206,14 +351,19 @@ func modifyEndpoint(endpointID string, settings string) (*HostComputeEndpoint, e
 	if err != nil {
 		return nil, err
 	}
+	callStart = traceStart()
 	hr = hcnQueryEndpointProperties(endpointHandle, string(query), &propertiesBuffer, &resultBuffer)
-	if err := checkForErrors("hcnQueryEndpointProperties", hr, resultBuffer); err != nil {
+	if err := traceEnd("hcnQueryEndpointProperties", callStart, checkForErrors("hcnQueryEndpointProperties", hr, &resultBuffer)); err != nil {
+		return nil, err
+	}
+	properties, err := decodeAndFree(propertiesBuffer)
+	if err != nil {
 		return nil, err
 	}
-	properties := interop.ConvertAndFreeCoTaskMemString(propertiesBuffer)
 	// Close endpoint.
+	callStart = traceStart()
 	hr = hcnCloseEndpoint(endpointHandle)
-	if err := checkForErrors("hcnCloseEndpoint", hr, nil); err != nil {
+	if err := traceEnd("hcnCloseEndpoint", callStart, checkForErrors("hcnCloseEndpoint", hr, nil)); err != nil {
 		return nil, err
 	}
 	// Convert output to HostComputeEndpoint
@@ -225,13 +375,15 @@ func modifyEndpoint(endpointID string, settings string) (*HostComputeEndpoint, e
 }
 
 func deleteEndpoint(endpointID string) error {
+	defer acquireSyscallSlot()()
 	endpointGUID, err := guid.FromString(endpointID)
 	if err != nil {
 		return errInvalidEndpointID
 	}
 	var resultBuffer *uint16
+	callStart := traceStart()
 	hr := hcnDeleteEndpoint(&endpointGUID, &resultBuffer)
-	if err := checkForErrors("hcnDeleteEndpoint", hr, resultBuffer); err != nil {
+	if err := traceEnd("hcnDeleteEndpoint", callStart, checkForErrors("hcnDeleteEndpoint", hr, &resultBuffer)); err != nil {
 		return err
 	}
 	return nil
@@ -247,6 +399,49 @@ func ListEndpoints() ([]HostComputeEndpoint, error) {
 	return endpoints, nil
 }
 
+// EndpointsByNetwork enumerates all endpoints once and buckets them by their
+// owning network's GUID, avoiding a per-network query when a caller wants the
+// full picture. The per-endpoint property queries run concurrently. If some
+// endpoints cannot be opened, the map still holds every endpoint that could
+// be, alongside the collected errors.
+func EndpointsByNetwork() (map[guid.GUID][]*HostComputeEndpoint, error) {
+	queryJSON, err := json.Marshal(defaultQuery())
+	if err != nil {
+		return nil, err
+	}
+	endpointIds, err := enumerateEndpointIds(string(queryJSON))
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		mu        sync.Mutex
+		byNetwork = make(map[guid.GUID][]*HostComputeEndpoint)
+		errs      []error
+	)
+	runBounded(len(endpointIds), func(i int) {
+		fail := func(err error) {
+			mu.Lock()
+			errs = append(errs, fmt.Errorf("endpoint %s: %w", endpointIds[i], err))
+			mu.Unlock()
+		}
+		endpoint, err := getEndpoint(endpointIds[i], string(queryJSON))
+		if err != nil {
+			fail(err)
+			return
+		}
+		networkGUID, err := guid.FromString(endpoint.HostComputeNetwork)
+		if err != nil {
+			fail(fmt.Errorf("invalid network ID %q: %w", endpoint.HostComputeNetwork, err))
+			return
+		}
+		mu.Lock()
+		byNetwork[networkGUID] = append(byNetwork[networkGUID], endpoint)
+		mu.Unlock()
+	})
+	return byNetwork, errors.Join(errs...)
+}
+
 // ListEndpointsQuery makes a call to query the list of available endpoints.
 func ListEndpointsQuery(query HostComputeQuery) ([]HostComputeEndpoint, error) {
 	queryJSON, err := json.Marshal(query)
@@ -261,8 +456,24 @@ func ListEndpointsQuery(query HostComputeQuery) ([]HostComputeEndpoint, error) {
 	return endpoints, nil
 }
 
+// ListEndpointIDs makes a call to query the list of available endpoints and
+// returns their GUIDs. Unlike ListEndpointsQuery, it does not open and query
+// each endpoint, making it much cheaper for existence checks.
+func ListEndpointIDs(query HostComputeQuery) ([]guid.GUID, error) {
+	queryJSON, err := json.Marshal(query)
+	if err != nil {
+		return nil, err
+	}
+
+	return enumerateEndpointIds(string(queryJSON))
+}
+
 // ListEndpointsOfNetwork queries the list of endpoints on a network.
 func ListEndpointsOfNetwork(networkID string) ([]HostComputeEndpoint, error) {
+	networkID, err := normalizeGUIDString("networkID", networkID)
+	if err != nil {
+		return nil, err
+	}
 	hcnQuery := defaultQuery()
 	// TODO: Once query can convert schema, change to {HostComputeNetwork:networkId}
 	mapA := map[string]string{"VirtualNetwork": networkID}
@@ -277,6 +488,10 @@ func ListEndpointsOfNetwork(networkID string) ([]HostComputeEndpoint, error) {
 
 // GetEndpointByID returns an endpoint specified by Id
 func GetEndpointByID(endpointID string) (*HostComputeEndpoint, error) {
+	endpointID, err := normalizeGUIDString("endpointID", endpointID)
+	if err != nil {
+		return nil, err
+	}
 	hcnQuery := defaultQuery()
 	mapA := map[string]string{"ID": endpointID}
 	filter, err := json.Marshal(mapA)
@@ -316,36 +531,541 @@ func GetEndpointByName(endpointName string) (*HostComputeEndpoint, error) {
 }
 
 // Create Endpoint.
-func (endpoint *HostComputeEndpoint) Create() (*HostComputeEndpoint, error) {
+func (endpoint *HostComputeEndpoint) Create(options ...CallOption) (*HostComputeEndpoint, error) {
 	logrus.Debugf("hcn::HostComputeEndpoint::Create id=%s", endpoint.Id)
 
 	if endpoint.HostComputeNamespace != "" {
 		return nil, errors.New("endpoint create error, endpoint json HostComputeNamespace is read only and should not be set")
 	}
 
-	jsonString, err := json.Marshal(endpoint)
+	callOpts := resolveCallOptions(options)
+	settings := *endpoint
+	if err := callOpts.applySchemaVersion(&settings.SchemaVersion); err != nil {
+		return nil, err
+	}
+	applyDefaultSchemaVersion(ObjectTypeEndpoint, &settings.SchemaVersion)
+
+	if settings.SkipDefaultGateway {
+		routes := make([]Route, 0, len(settings.Routes))
+		for _, route := range settings.Routes {
+			if !isDefaultRoute(route.DestinationPrefix) {
+				routes = append(routes, route)
+			}
+		}
+		settings.Routes = routes
+		if len(settings.IpConfigurations) == 0 && len(settings.Routes) == 0 {
+			return nil, errors.New("endpoint create error, skipping the default gateway leaves no connectivity configuration")
+		}
+	}
+
+	if err := validateIpConfigurations(settings.IpConfigurations); err != nil {
+		return nil, err
+	}
+
+	jsonString, err := json.Marshal(&settings)
 	if err != nil {
 		return nil, err
 	}
 
 	logrus.Debugf("hcn::HostComputeEndpoint::Create JSON: %s", jsonString)
-	endpoint, hcnErr := createEndpoint(endpoint.HostComputeNetwork, string(jsonString))
+	created, hcnErr := createEndpoint(endpoint.HostComputeNetwork, string(jsonString))
 	if hcnErr != nil {
 		return nil, hcnErr
 	}
-	return endpoint, nil
+
+	// createEndpoint returns the queried endpoint, so this checks what HNS
+	// actually installed, not what was requested.
+	if settings.SkipDefaultGateway {
+		for _, route := range created.Routes {
+			if isDefaultRoute(route.DestinationPrefix) {
+				if deleteErr := created.Delete(); deleteErr != nil {
+					return nil, fmt.Errorf("HNS installed default route %s despite SkipDefaultGateway; rollback delete also failed: %v", route.DestinationPrefix, deleteErr)
+				}
+				return nil, fmt.Errorf("HNS installed default route %s despite SkipDefaultGateway (endpoint rolled back)", route.DestinationPrefix)
+			}
+		}
+	}
+
+	// Policies in the endpoint document are created atomically with the
+	// endpoint; PostCreatePolicies are not. They are applied as an immediate
+	// follow-up modify, and if that fails the endpoint is deleted again, so
+	// the caller never observes an endpoint missing its required policies.
+	// This is a cleanup-on-failure sequence, not a transaction: the endpoint
+	// briefly exists without the policies while the modify is in flight.
+	if len(endpoint.PostCreatePolicies) > 0 {
+		err := created.ApplyPolicy(RequestTypeAdd, PolicyEndpointRequest{Policies: endpoint.PostCreatePolicies})
+		if err != nil {
+			if deleteErr := created.Delete(); deleteErr != nil {
+				return nil, fmt.Errorf("apply post-create policies to endpoint %s: %w; rollback delete also failed: %v", created.Id, err, deleteErr)
+			}
+			return nil, fmt.Errorf("apply post-create policies: %w (endpoint rolled back)", err)
+		}
+	}
+	return created, nil
+}
+
+// EnsureEndpoint is the idempotent upsert for reconcilers: if an endpoint
+// with the GUID already exists it is adopted and brought to the desired state
+// with the minimal modifies DiffEndpoint computes, and created=false is
+// returned; otherwise the endpoint is created under that GUID with
+// created=true. Either way the returned endpoint reflects the state HNS holds
+// after the call.
+func EnsureEndpoint(id guid.GUID, desired *HostComputeEndpoint) (*HostComputeEndpoint, bool, error) {
+	endpoint, created, _, err := ensureEndpoint(id, desired)
+	return endpoint, created, err
+}
+
+// ensureEndpoint is EnsureEndpoint additionally reporting whether an adopted
+// endpoint needed any modifies, which Apply uses to tell an endpoint it
+// updated from one that already matched the spec.
+func ensureEndpoint(id guid.GUID, desired *HostComputeEndpoint) (*HostComputeEndpoint, bool, bool, error) {
+	logrus.Debugf("hcn::HostComputeEndpoint::EnsureEndpoint id=%s", id)
+
+	current, err := GetEndpointByID(id.String())
+	if err != nil {
+		if !IsNotFoundError(err) {
+			return nil, false, false, err
+		}
+		settings := *desired
+		settings.Id = id.String()
+		created, err := settings.Create()
+		if err != nil {
+			return nil, false, false, err
+		}
+		return created, true, false, nil
+	}
+
+	requests, changed, err := DiffEndpoint(current, desired)
+	if err != nil {
+		return nil, false, false, err
+	}
+	if !changed {
+		return current, false, false, nil
+	}
+	for _, request := range requests {
+		if err := ModifyEndpointSettings(current.Id, request); err != nil {
+			return nil, false, false, fmt.Errorf("reconcile endpoint %s: %w", current.Id, err)
+		}
+	}
+	reconciled, err := GetEndpointByID(current.Id)
+	if err != nil {
+		return nil, false, false, err
+	}
+	return reconciled, false, true, nil
+}
+
+// CreateOrOpenEndpoint creates the endpoint under the given ID, or, when one
+// with that ID already exists, returns the existing endpoint instead of
+// failing. The boolean reports whether this call created it. Unlike
+// EnsureEndpoint, an existing endpoint is returned as-is rather than
+// reconciled against desired.
+func CreateOrOpenEndpoint(id guid.GUID, desired *HostComputeEndpoint) (*HostComputeEndpoint, bool, error) {
+	logrus.Debugf("hcn::HostComputeEndpoint::CreateOrOpenEndpoint id=%s", id)
+
+	settings := *desired
+	settings.Id = id.String()
+	created, err := settings.Create()
+	if err == nil {
+		return created, true, nil
+	}
+	if !IsAlreadyExistsError(err) {
+		return nil, false, err
+	}
+	existing, err := GetEndpointByID(id.String())
+	if err != nil {
+		return nil, false, err
+	}
+	return existing, false, nil
+}
+
+// DeleteEndpointAndWait deletes the endpoint and blocks until HNS no longer
+// reports it, or ctx expires. hcnDeleteEndpoint can return before the object
+// is fully reclaimed, so an enumeration immediately after a plain delete may
+// still list the endpoint; this variant gives teardown and sequential test
+// steps a delete they can order against.
+func DeleteEndpointAndWait(ctx context.Context, id guid.GUID) error {
+	logrus.Debugf("hcn::HostComputeEndpoint::DeleteEndpointAndWait id=%s", id)
+
+	if err := deleteEndpoint(id.String()); err != nil {
+		return err
+	}
+
+	query, err := json.Marshal(defaultQuery())
+	if err != nil {
+		return err
+	}
+	return pollUntil(ctx, DefaultPollOptions(), func() (bool, error) {
+		endpointIds, err := enumerateEndpointIds(string(query))
+		if err != nil {
+			return false, err
+		}
+		return !slices.Contains(endpointIds, id), nil
+	})
+}
+
+// AssignmentMode reports how an endpoint's IP addresses were assigned.
+type AssignmentMode string
+
+var (
+	// AssignmentModeStatic means the requested static addresses were used.
+	AssignmentModeStatic AssignmentMode = "Static"
+	// AssignmentModeDynamic means the addresses were assigned by IPAM.
+	AssignmentModeDynamic AssignmentMode = "Dynamic"
+)
+
+// CreateWithDynamicFallback creates the endpoint like Create, preferring the
+// requested static IP configurations. When creation fails because a requested
+// address conflicts and every IpConfig has opted in via AllowDynamicFallback,
+// creation is retried with dynamic IPAM. It reports which assignment mode was
+// used; the returned endpoint carries the final assigned addresses.
+func (endpoint *HostComputeEndpoint) CreateWithDynamicFallback() (*HostComputeEndpoint, AssignmentMode, error) {
+	created, err := endpoint.Create()
+	if err == nil {
+		mode := AssignmentModeDynamic
+		if len(endpoint.IpConfigurations) > 0 {
+			mode = AssignmentModeStatic
+		}
+		return created, mode, nil
+	}
+	if !IsAddressConflictError(err) || len(endpoint.IpConfigurations) == 0 {
+		return nil, "", err
+	}
+	for _, ipConfig := range endpoint.IpConfigurations {
+		if !ipConfig.AllowDynamicFallback {
+			return nil, "", err
+		}
+	}
+
+	logrus.Debugf("hcn::HostComputeEndpoint::CreateWithDynamicFallback static create failed, retrying with dynamic assignment: %s", err)
+	fallback := *endpoint
+	fallback.IpConfigurations = nil
+	created, fallbackErr := fallback.Create()
+	if fallbackErr != nil {
+		return nil, "", fmt.Errorf("dynamic fallback failed: %w (static create error: %s)", fallbackErr, err)
+	}
+	return created, AssignmentModeDynamic, nil
+}
+
+// CreateEndpointAndGetIP creates an endpoint on the network specified by
+// networkID and waits, bounded by ctx, for IPAM to populate the endpoint's IP
+// configuration, returning the assigned addresses. Both IPv4 and IPv6
+// addresses are returned on dual-stack networks. If the context expires before
+// an address appears, the error includes the last observed endpoint state.
+func CreateEndpointAndGetIP(ctx context.Context, networkID string, settings *HostComputeEndpoint) (*HostComputeEndpoint, []netip.Addr, error) {
+	networkID, err := normalizeGUIDString("networkID", networkID)
+	if err != nil {
+		return nil, nil, err
+	}
+	logrus.Debugf("hcn::CreateEndpointAndGetIP networkId=%s", networkID)
+
+	settings.HostComputeNetwork = networkID
+	settingsJSON, err := json.Marshal(settings)
+	if err != nil {
+		return nil, nil, err
+	}
+	endpoint, err := createEndpoint(networkID, string(settingsJSON))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var addrs []netip.Addr
+	pollErr := pollUntil(ctx, DefaultPollOptions(), func() (bool, error) {
+		addrs, err = endpointAddresses(endpoint)
+		if err != nil {
+			return false, err
+		}
+		if len(addrs) > 0 {
+			return true, nil
+		}
+		endpoint, err = GetEndpointByID(endpoint.Id)
+		return false, err
+	})
+	if pollErr != nil {
+		if ctx.Err() != nil && errors.Is(pollErr, ctx.Err()) {
+			lastState, marshalErr := json.Marshal(endpoint)
+			if marshalErr != nil {
+				lastState = []byte(marshalErr.Error())
+			}
+			return endpoint, nil, fmt.Errorf("endpoint %s has no IP configuration, last observed state: %s: %w", endpoint.Id, lastState, pollErr)
+		}
+		return endpoint, nil, pollErr
+	}
+	return endpoint, addrs, nil
+}
+
+// CreateEndpointContext is CreateEndpointAndGetIP with cleanup on
+// cancellation: if ctx is canceled before the create syscall runs, it returns
+// immediately with no side effects, and if ctx expires while waiting for IPAM
+// after the endpoint has been created, the just-created endpoint is deleted
+// before returning ctx's error, so no orphan is left behind.
+func CreateEndpointContext(ctx context.Context, networkID string, settings *HostComputeEndpoint) (*HostComputeEndpoint, []netip.Addr, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, nil, err
+	}
+	endpoint, addrs, err := CreateEndpointAndGetIP(ctx, networkID, settings)
+	if err != nil && endpoint != nil && ctx.Err() != nil {
+		if deleteErr := endpoint.Delete(); deleteErr != nil {
+			return nil, nil, fmt.Errorf("cleanup of endpoint %s failed: %w: %s", endpoint.Id, err, deleteErr)
+		}
+		return nil, nil, err
+	}
+	return endpoint, addrs, err
+}
+
+// CreateReport breaks an endpoint creation down into its phases so callers
+// can attribute latency to a specific step without external profiling.
+type CreateReport struct {
+	// SchemaVersion is the version the settings document was marshaled
+	// with, after defaults were applied.
+	SchemaVersion SchemaVersion
+	// MarshalDuration covers building the JSON settings document.
+	MarshalDuration time.Duration
+	// SyscallDuration covers the HcnCreateEndpoint call itself.
+	SyscallDuration time.Duration
+	// IPWaitDuration covers waiting for IPAM to populate the endpoint's IP
+	// configurations.
+	IPWaitDuration time.Duration
+	// TotalDuration is the wall time of the whole operation.
+	TotalDuration time.Duration
+}
+
+// CreateEndpointWithReport is CreateEndpointAndGetIP returning a per-phase
+// timing breakdown alongside the endpoint. The report is populated for the
+// phases that ran even when the create fails, so failed attempts can be
+// attributed too; it is nil only if the arguments were rejected before any
+// phase started.
+func CreateEndpointWithReport(ctx context.Context, networkID string, settings *HostComputeEndpoint) (*HostComputeEndpoint, *CreateReport, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, nil, err
+	}
+	networkID, err := normalizeGUIDString("networkID", networkID)
+	if err != nil {
+		return nil, nil, err
+	}
+	logrus.Debugf("hcn::CreateEndpointWithReport networkId=%s", networkID)
+
+	doc := *settings
+	doc.HostComputeNetwork = networkID
+	applyDefaultSchemaVersion(ObjectTypeEndpoint, &doc.SchemaVersion)
+	report := &CreateReport{SchemaVersion: doc.SchemaVersion}
+
+	start := time.Now()
+	settingsJSON, err := json.Marshal(&doc)
+	report.MarshalDuration = time.Since(start)
+	if err != nil {
+		report.TotalDuration = time.Since(start)
+		return nil, report, err
+	}
+
+	syscallStart := time.Now()
+	endpoint, err := createEndpoint(networkID, string(settingsJSON))
+	report.SyscallDuration = time.Since(syscallStart)
+	if err != nil {
+		report.TotalDuration = time.Since(start)
+		return nil, report, err
+	}
+
+	waitStart := time.Now()
+	pollErr := pollUntil(ctx, DefaultPollOptions(), func() (bool, error) {
+		if len(endpoint.IpConfigurations) > 0 {
+			return true, nil
+		}
+		endpoint, err = GetEndpointByID(endpoint.Id)
+		return false, err
+	})
+	report.IPWaitDuration = time.Since(waitStart)
+	report.TotalDuration = time.Since(start)
+	if pollErr != nil {
+		return endpoint, report, pollErr
+	}
+	return endpoint, report, nil
+}
+
+// endpointAddresses parses the addresses out of the endpoint's IP
+// configurations.
+func endpointAddresses(endpoint *HostComputeEndpoint) ([]netip.Addr, error) {
+	var addrs []netip.Addr
+	for _, ipConfig := range endpoint.IpConfigurations {
+		addr, err := netip.ParseAddr(ipConfig.IpAddress)
+		if err != nil {
+			return nil, fmt.Errorf("endpoint %s has invalid IP address %q: %w", endpoint.Id, ipConfig.IpAddress, err)
+		}
+		addrs = append(addrs, addr)
+	}
+	return addrs, nil
 }
 
 // Delete Endpoint.
 func (endpoint *HostComputeEndpoint) Delete() error {
 	logrus.Debugf("hcn::HostComputeEndpoint::Delete id=%s", endpoint.Id)
 
+	if endpointGUID, err := guid.FromString(endpoint.Id); err == nil {
+		if count := sharedCompartmentCount(endpointGUID); count > 0 {
+			return fmt.Errorf("endpoint %s is shared into %d compartment(s); unshare it before deleting", endpoint.Id, count)
+		}
+	}
+
 	if err := deleteEndpoint(endpoint.Id); err != nil {
 		return err
 	}
 	return nil
 }
 
+// EndpointStats are the per-endpoint traffic counters HNS maintains.
+type EndpointStats struct {
+	BytesReceived          uint64 `json:",omitempty"`
+	BytesSent              uint64 `json:",omitempty"`
+	PacketsReceived        uint64 `json:",omitempty"`
+	PacketsSent            uint64 `json:",omitempty"`
+	DroppedPacketsIncoming uint64 `json:",omitempty"`
+	DroppedPacketsOutgoing uint64 `json:",omitempty"`
+}
+
+// Statistics queries the endpoint's traffic counters, bounded by ctx. The
+// counters are part of the detailed property set, which not every host
+// populates — notably older builds and endpoints that have never carried
+// traffic; in that case ErrStatisticsUnavailable is returned rather than a
+// zeroed struct, so exporters can tell "no counters" from "no traffic".
+func (endpoint *HostComputeEndpoint) Statistics(ctx context.Context) (*EndpointStats, error) {
+	logrus.Debugf("hcn::HostComputeEndpoint::Statistics id=%s", endpoint.Id)
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	endpointGUID, err := guid.FromString(endpoint.Id)
+	if err != nil {
+		return nil, errInvalidEndpointID
+	}
+	query := defaultQuery()
+	query.Flags = HostComputeQueryFlagsDetailed
+	queryJSON, err := json.Marshal(query)
+	if err != nil {
+		return nil, err
+	}
+
+	properties, err := awaitContext(ctx, func() (string, error) {
+		return queryEndpointProperties(endpointGUID, string(queryJSON))
+	}, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var document struct {
+		Statistics *EndpointStats `json:",omitempty"`
+	}
+	if err := json.Unmarshal([]byte(properties), &document); err != nil {
+		return nil, fmt.Errorf("parse endpoint %s properties: %w", endpoint.Id, err)
+	}
+	if document.Statistics == nil {
+		return nil, fmt.Errorf("endpoint %s: %w", endpoint.Id, ErrStatisticsUnavailable)
+	}
+	return document.Statistics, nil
+}
+
+// ModifyEndpointSettingsWithResult is ModifyEndpointSettings returning the
+// JSON diagnostic document HCN produced for the call alongside the error.
+// When a modify is rejected the document describes exactly what HCN objected
+// to — for example which policy failed validation — which the bare error
+// string does not. On success HCN produces no document and the string is
+// empty.
+func ModifyEndpointSettingsWithResult(endpointID string, request *ModifyEndpointSettingRequest) (string, error) {
+	err := ModifyEndpointSettings(endpointID, request)
+	return ResultDocument(err), err
+}
+
+// AttachEndpointsToCompartment attaches every endpoint to the given network
+// compartment. HNS has no bulk modify call — each attach is its own
+// HcnModifyEndpoint — so the requests are issued concurrently with bounded
+// parallelism rather than serially, which is what makes dense pod setup
+// tolerable. Failures do not stop the others: the returned error joins one
+// entry per endpoint that could not be attached, and nil means all were.
+func AttachEndpointsToCompartment(endpoints []*HostComputeEndpoint, compartmentID uint32) error {
+	logrus.Debugf("hcn::AttachEndpointsToCompartment compartment=%d endpoints=%d", compartmentID, len(endpoints))
+
+	settingsJSON, err := json.Marshal(compartmentShareSetting{CompartmentId: compartmentID})
+	if err != nil {
+		return err
+	}
+	requestMessage := &ModifyEndpointSettingRequest{
+		ResourceType: EndpointResourceTypeCompartment,
+		RequestType:  RequestTypeAdd,
+		Settings:     settingsJSON,
+	}
+
+	var (
+		mu   sync.Mutex
+		errs []error
+	)
+	runBounded(len(endpoints), func(i int) {
+		if err := ModifyEndpointSettings(endpoints[i].Id, requestMessage); err != nil {
+			mu.Lock()
+			errs = append(errs, fmt.Errorf("endpoint %s: %w", endpoints[i].Id, err))
+			mu.Unlock()
+		}
+	})
+	return errors.Join(errs...)
+}
+
+// CascadeReport records which cleanup steps DeleteCascade performed, so
+// callers can audit a teardown that spans several objects.
+type CascadeReport struct {
+	// LoadBalancersDetached is the number of load balancers the endpoint
+	// was removed from.
+	LoadBalancersDetached int
+	// NamespaceDetached reports whether the endpoint was removed from a
+	// namespace.
+	NamespaceDetached bool
+	// Deleted reports whether the endpoint itself was deleted by this
+	// call. False with a nil error means it was already gone.
+	Deleted bool
+}
+
+// DeleteCascade deletes the endpoint after first removing it from every load
+// balancer that references it as a backend and detaching it from its
+// namespace, so no dangling references are left behind. Each step is
+// idempotent — references that are already gone are skipped — so a retry
+// after a partial failure completes the remaining teardown. The returned
+// report records which steps actually ran.
+func (endpoint *HostComputeEndpoint) DeleteCascade() (*CascadeReport, error) {
+	logrus.Debugf("hcn::HostComputeEndpoint::DeleteCascade id=%s", endpoint.Id)
+
+	report := &CascadeReport{}
+	current, err := GetEndpointByID(endpoint.Id)
+	if err != nil {
+		if IsNotFoundError(err) {
+			return report, nil
+		}
+		return report, err
+	}
+
+	loadBalancers, err := LoadBalancersForEndpoint(current.Id)
+	if err != nil {
+		return report, fmt.Errorf("finding load balancers referencing endpoint %s: %w", current.Id, err)
+	}
+	for i := range loadBalancers {
+		if _, err := loadBalancers[i].RemoveEndpoint(current); err != nil {
+			return report, fmt.Errorf("removing endpoint %s from load balancer %s: %w", current.Id, loadBalancers[i].Id, err)
+		}
+		report.LoadBalancersDetached++
+	}
+
+	if current.HostComputeNamespace != "" {
+		if err := RemoveNamespaceEndpoint(current.HostComputeNamespace, current.Id); err != nil && !IsNotFoundError(err) {
+			return report, fmt.Errorf("detaching endpoint %s from namespace %s: %w", current.Id, current.HostComputeNamespace, err)
+		}
+		report.NamespaceDetached = true
+	}
+
+	if err := current.Delete(); err != nil {
+		if IsNotFoundError(err) {
+			return report, nil
+		}
+		return report, err
+	}
+	report.Deleted = true
+	return report, nil
+}
+
 // ModifyEndpointSettings updates the Port/Policy of an Endpoint.
 func ModifyEndpointSettings(endpointID string, request *ModifyEndpointSettingRequest) error {
 	logrus.Debugf("hcn::HostComputeEndpoint::ModifyEndpointSettings id=%s", endpointID)
@@ -379,12 +1099,778 @@ func (endpoint *HostComputeEndpoint) ApplyPolicy(requestType RequestType, endpoi
 	return ModifyEndpointSettings(endpoint.Id, requestMessage)
 }
 
-// NamespaceAttach modifies a Namespace to add an endpoint.
-func (endpoint *HostComputeEndpoint) NamespaceAttach(namespaceID string) error {
-	return AddNamespaceEndpoint(namespaceID, endpoint.Id)
-}
+// SetDNS replaces the endpoint's DNS configuration with the given servers and
+// search list, so callers no longer hand-edit the DNS section of the settings
+// document. Server addresses are validated before the call and the search
+// list is deduplicated with order preserved. On success the receiver's Dns
+// field reflects the new configuration.
+func (endpoint *HostComputeEndpoint) SetDNS(servers []netip.Addr, searchList []string) error {
+	logrus.Debugf("hcn::HostComputeEndpoint::SetDNS id=%s", endpoint.Id)
 
-// NamespaceDetach modifies a Namespace to remove an endpoint.
-func (endpoint *HostComputeEndpoint) NamespaceDetach(namespaceID string) error {
-	return RemoveNamespaceEndpoint(namespaceID, endpoint.Id)
+	serverList := make([]string, 0, len(servers))
+	for _, server := range servers {
+		if !server.IsValid() || server.IsUnspecified() {
+			return fmt.Errorf("invalid DNS server address %s", server)
+		}
+		serverList = append(serverList, server.String())
+	}
+	search := make([]string, 0, len(searchList))
+	for _, suffix := range searchList {
+		if !slices.Contains(search, suffix) {
+			search = append(search, suffix)
+		}
+	}
+
+	dns := Dns{
+		ServerList: serverList,
+		Search:     search,
+	}
+	settingsJSON, err := json.Marshal(dns)
+	if err != nil {
+		return err
+	}
+	requestMessage := &ModifyEndpointSettingRequest{
+		ResourceType: EndpointResourceTypeDNS,
+		RequestType:  RequestTypeUpdate,
+		Settings:     settingsJSON,
+	}
+
+	if err := ModifyEndpointSettings(endpoint.Id, requestMessage); err != nil {
+		return err
+	}
+	endpoint.Dns = dns
+	return nil
+}
+
+// IPConfigFromPrefix converts prefix into the IpConfig document HCN expects
+// for a static address assignment, so callers building dual-stack endpoints
+// work with netip values instead of hand-assembled strings.
+func IPConfigFromPrefix(prefix netip.Prefix) (IpConfig, error) {
+	if !prefix.IsValid() {
+		return IpConfig{}, fmt.Errorf("invalid address prefix %v", prefix)
+	}
+	return IpConfig{
+		IpAddress:    prefix.Addr().String(),
+		PrefixLength: uint8(prefix.Bits()),
+	}, nil
+}
+
+// NewIPv6EndpointPolicy returns the IP configuration and default route that
+// give an endpoint the IPv6 address in prefix with gw as its gateway. Append
+// both to the endpoint's IpConfigurations and Routes alongside the IPv4
+// entries to build a dual-stack endpoint; Create validates that each
+// configuration's prefix length is feasible for its address family.
+func NewIPv6EndpointPolicy(prefix netip.Prefix, gw netip.Addr) (IpConfig, Route, error) {
+	if !prefix.Addr().Is6() || prefix.Addr().Is4In6() {
+		return IpConfig{}, Route{}, fmt.Errorf("prefix %s is not IPv6", prefix)
+	}
+	if !gw.Is6() || gw.Is4In6() {
+		return IpConfig{}, Route{}, fmt.Errorf("gateway %s is not IPv6", gw)
+	}
+	ipConfig, err := IPConfigFromPrefix(prefix)
+	if err != nil {
+		return IpConfig{}, Route{}, err
+	}
+	route := Route{
+		NextHop:           gw.String(),
+		DestinationPrefix: "::/0",
+	}
+	return ipConfig, route, nil
+}
+
+// validateIpConfigurations rejects IP configuration lists where an address
+// carries a prefix length that is not feasible for its family — the usual
+// symptom of mixing IPv4 and IPv6 entries and crossing their prefix lengths.
+// HCN accepts such documents and fails much later, during attach.
+func validateIpConfigurations(ipConfigs []IpConfig) error {
+	for i, ipConfig := range ipConfigs {
+		if ipConfig.IpAddress == "" {
+			continue
+		}
+		addr, err := netip.ParseAddr(ipConfig.IpAddress)
+		if err != nil {
+			return fmt.Errorf("ip configuration %d has invalid address %q: %w", i, ipConfig.IpAddress, err)
+		}
+		bits := 128
+		if addr.Is4() {
+			bits = 32
+		}
+		if int(ipConfig.PrefixLength) > bits {
+			return fmt.Errorf("ip configuration %d: prefix length %d is not valid for %s", i, ipConfig.PrefixLength, ipConfig.IpAddress)
+		}
+	}
+	return nil
+}
+
+// WithProxyARP makes the endpoint respond to ARP requests for its gateway
+// address itself, for use before Create. This is only valid on L2Bridge and
+// L2Tunnel networks, where the endpoint shares the host's L2 segment; Create
+// rejects it on other network types.
+func (endpoint *HostComputeEndpoint) WithProxyARP() *HostComputeEndpoint {
+	settings, _ := json.Marshal(ArpProxyEndpointPolicySetting{Enabled: true})
+	endpoint.Policies = append(endpoint.Policies, EndpointPolicy{
+		Type:     ArpProxy,
+		Settings: settings,
+	})
+	return endpoint
+}
+
+// WithGatewayMAC overrides the MAC address the endpoint resolves for its
+// gateway, for use before Create. Like WithProxyARP this is only valid on
+// L2Bridge and L2Tunnel networks; Create rejects it on other network types.
+func (endpoint *HostComputeEndpoint) WithGatewayMAC(mac string) (*HostComputeEndpoint, error) {
+	policy, err := NewGatewayMacEndpointPolicy(mac)
+	if err != nil {
+		return nil, err
+	}
+	endpoint.Policies = append(endpoint.Policies, *policy)
+	return endpoint, nil
+}
+
+// WithMacAddress pre-assigns the endpoint's MAC address, for use before
+// Create in SR-IOV and VM scenarios that need a deterministic MAC instead of
+// one HNS picks from the network's pool. The address must be a unicast
+// 6-octet MAC; multicast and broadcast addresses are rejected. It is stored
+// in the dash-separated uppercase form HNS reports back, so a created
+// endpoint round-trips equal.
+func (endpoint *HostComputeEndpoint) WithMacAddress(mac string) (*HostComputeEndpoint, error) {
+	hwAddr, err := net.ParseMAC(mac)
+	if err != nil {
+		return nil, fmt.Errorf("invalid MAC address %q: %w", mac, err)
+	}
+	if len(hwAddr) != 6 {
+		return nil, fmt.Errorf("MAC address %q is not 6 octets", mac)
+	}
+	if hwAddr[0]&0x01 != 0 {
+		return nil, fmt.Errorf("MAC address %q is a multicast or broadcast address", mac)
+	}
+	endpoint.MacAddress = strings.ToUpper(strings.ReplaceAll(hwAddr.String(), ":", "-"))
+	return endpoint, nil
+}
+
+// WithConfidential sets the EndpointFlagsConfidential flag for confidential
+// container workloads, for use before Create. It requires Windows Server
+// 2025 (HNS 15.4); on older builds ErrAPIUnsupported is returned.
+func (endpoint *HostComputeEndpoint) WithConfidential() (*HostComputeEndpoint, error) {
+	if err := confidentialSupported(); err != nil {
+		return nil, err
+	}
+	return endpoint.WithEndpointFlags(EndpointFlagsConfidential)
+}
+
+// WithEndpointFlags sets creation flags on the endpoint, for use before
+// Create. The combination is validated: unknown bits are rejected, as are
+// combinations HNS does not support. The flags round-trip through query, so
+// endpoints read back from the service report what was set.
+func (endpoint *HostComputeEndpoint) WithEndpointFlags(flags EndpointFlags) (*HostComputeEndpoint, error) {
+	combined := endpoint.Flags | flags
+	if err := combined.validate(); err != nil {
+		return nil, err
+	}
+	endpoint.Flags = combined
+	return endpoint, nil
+}
+
+// WithProviderAddress sets the endpoint's provider address (PA), for use
+// before Create. On overlay networks the PA is the host-facing address
+// encapsulated traffic is carried on, distinct from the customer address the
+// endpoint's IP configurations hold. The address must be a routable host
+// address: unspecified, loopback, and multicast addresses are rejected.
+func (endpoint *HostComputeEndpoint) WithProviderAddress(addr string) (*HostComputeEndpoint, error) {
+	parsed, err := netip.ParseAddr(addr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid provider address %q: %w", addr, err)
+	}
+	if parsed.IsUnspecified() || parsed.IsLoopback() || parsed.IsMulticast() {
+		return nil, fmt.Errorf("provider address %q is not a routable host address", addr)
+	}
+	settings, err := json.Marshal(ProviderAddressEndpointPolicySetting{ProviderAddress: addr})
+	if err != nil {
+		return nil, err
+	}
+	endpoint.Policies = append(endpoint.Policies, EndpointPolicy{
+		Type:     NetworkProviderAddress,
+		Settings: settings,
+	})
+	return endpoint, nil
+}
+
+// ProviderAddress returns the endpoint's provider address from its
+// ProviderAddress policy. Endpoints without the policy return the zero
+// netip.Addr.
+func (endpoint *HostComputeEndpoint) ProviderAddress() (netip.Addr, error) {
+	for _, policy := range endpoint.Policies {
+		if policy.Type != NetworkProviderAddress {
+			continue
+		}
+		var setting ProviderAddressEndpointPolicySetting
+		if err := json.Unmarshal(policy.Settings, &setting); err != nil {
+			return netip.Addr{}, err
+		}
+		addr, err := netip.ParseAddr(setting.ProviderAddress)
+		if err != nil {
+			return netip.Addr{}, fmt.Errorf("endpoint %s has invalid provider address %q: %w", endpoint.Id, setting.ProviderAddress, err)
+		}
+		return addr, nil
+	}
+	return netip.Addr{}, nil
+}
+
+// MTUSource identifies where an endpoint's effective MTU came from.
+type MTUSource string
+
+const (
+	// MTUSourceEndpoint means the endpoint's own Mtu policy set the value.
+	MTUSourceEndpoint MTUSource = "Endpoint"
+	// MTUSourceNetwork means the value was inherited from the owning network.
+	MTUSourceNetwork MTUSource = "Network"
+	// MTUSourceDefault means neither specifies one and the platform default
+	// applies.
+	MTUSourceDefault MTUSource = "Default"
+)
+
+// defaultMTU is the Ethernet default HNS uses when no policy overrides it.
+const defaultMTU uint32 = 1500
+
+// EffectiveMTU returns the MTU the endpoint actually operates with, and
+// where it came from: the endpoint's own Mtu policy when present, otherwise
+// the owning network's, otherwise the platform default. The source lets
+// callers distinguish an explicit per-endpoint override from inheritance.
+func (endpoint *HostComputeEndpoint) EffectiveMTU() (uint32, MTUSource, error) {
+	for _, policy := range endpoint.Policies {
+		if policy.Type != Mtu {
+			continue
+		}
+		var setting MtuPolicySetting
+		if err := json.Unmarshal(policy.Settings, &setting); err != nil {
+			return 0, "", fmt.Errorf("endpoint %s has invalid Mtu policy settings: %w", endpoint.Id, err)
+		}
+		return setting.Mtu, MTUSourceEndpoint, nil
+	}
+
+	if endpoint.HostComputeNetwork != "" {
+		network, err := GetNetworkByID(endpoint.HostComputeNetwork)
+		if err != nil {
+			return 0, "", err
+		}
+		for _, policy := range network.Policies {
+			if policy.Type != NetworkMtu {
+				continue
+			}
+			var setting MtuPolicySetting
+			if err := json.Unmarshal(policy.Settings, &setting); err != nil {
+				return 0, "", fmt.Errorf("network %s has invalid Mtu policy settings: %w", network.Id, err)
+			}
+			return setting.Mtu, MTUSourceNetwork, nil
+		}
+	}
+	return defaultMTU, MTUSourceDefault, nil
+}
+
+// WithRoutes sets the endpoint's routes, for use before Create. Each route's
+// destination prefix must be valid CIDR notation and each next hop a valid IP
+// address; metrics are passed through unchecked since HNS accepts the full
+// range. The Routes field on a queried endpoint already carries the typed
+// entries, and QueryRoutes refreshes them.
+func (endpoint *HostComputeEndpoint) WithRoutes(routes []Route) (*HostComputeEndpoint, error) {
+	for _, route := range routes {
+		if _, err := netip.ParsePrefix(route.DestinationPrefix); err != nil {
+			return nil, fmt.Errorf("invalid route destination %q: %w", route.DestinationPrefix, err)
+		}
+		if _, err := netip.ParseAddr(route.NextHop); err != nil {
+			return nil, fmt.Errorf("invalid route next hop %q: %w", route.NextHop, err)
+		}
+	}
+	endpoint.Routes = routes
+	return endpoint, nil
+}
+
+// QueryRoutes re-queries the endpoint from HNS and returns its routes as
+// typed entries, so callers get current state without re-parsing raw
+// properties. The endpoint's Routes field reflects the last query and can be
+// stale.
+func (endpoint *HostComputeEndpoint) QueryRoutes() ([]Route, error) {
+	logrus.Debugf("hcn::HostComputeEndpoint::QueryRoutes id=%s", endpoint.Id)
+
+	queried, err := GetEndpointByID(endpoint.Id)
+	if err != nil {
+		return nil, err
+	}
+	endpoint.Routes = queried.Routes
+	return queried.Routes, nil
+}
+
+// WithCustomerAddress sets the endpoint's customer address (CA), for use
+// before Create. On overlay networks the CA is the address the workload sees,
+// distinct from the provider address carrying the encapsulated traffic; set
+// both with this and WithProviderAddress for deterministic-IP scenarios. The
+// endpoint's HostComputeNetwork must already be set, since the address is
+// validated against that network's customer subnets.
+func (endpoint *HostComputeEndpoint) WithCustomerAddress(addr string) (*HostComputeEndpoint, error) {
+	parsed, err := netip.ParseAddr(addr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid customer address %q: %w", addr, err)
+	}
+	if endpoint.HostComputeNetwork == "" {
+		return nil, errors.New("endpoint must reference a network before setting a customer address")
+	}
+	network, err := GetNetworkByID(endpoint.HostComputeNetwork)
+	if err != nil {
+		return nil, err
+	}
+	var prefix netip.Prefix
+	found := false
+	for _, ipam := range network.Ipams {
+		for _, subnet := range ipam.Subnets {
+			subnetPrefix, err := netip.ParsePrefix(subnet.IpAddressPrefix)
+			if err == nil && subnetPrefix.Contains(parsed) {
+				prefix = subnetPrefix
+				found = true
+			}
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("customer address %q is not within any subnet of network %s", addr, network.Id)
+	}
+	endpoint.IpConfigurations = append(endpoint.IpConfigurations, IpConfig{
+		IpAddress:    addr,
+		PrefixLength: uint8(prefix.Bits()),
+	})
+	return endpoint, nil
+}
+
+// CustomerAddress returns the endpoint's customer address from its IP
+// configurations. Endpoints without one return the zero netip.Addr.
+func (endpoint *HostComputeEndpoint) CustomerAddress() (netip.Addr, error) {
+	for _, ipConfig := range endpoint.IpConfigurations {
+		addr, err := netip.ParseAddr(ipConfig.IpAddress)
+		if err != nil {
+			return netip.Addr{}, fmt.Errorf("endpoint %s has invalid address %q: %w", endpoint.Id, ipConfig.IpAddress, err)
+		}
+		return addr, nil
+	}
+	return netip.Addr{}, nil
+}
+
+// WithSwitchPortID binds the endpoint to a pre-created vSwitch port, for use
+// before Create. This is required when the port is provisioned out of band,
+// such as SR-IOV virtual function assignment where the VF's switch port must
+// be created before the endpoint that uses it. Honoring the field requires
+// IOV support from HNS; builds without it return ErrAPIUnsupported.
+func (endpoint *HostComputeEndpoint) WithSwitchPortID(portID string) (*HostComputeEndpoint, error) {
+	portID, err := normalizeGUIDString("portID", portID)
+	if err != nil {
+		return nil, err
+	}
+	caps, err := Capabilities()
+	if err != nil {
+		return nil, err
+	}
+	if !caps.IOV {
+		return nil, fmt.Errorf("switch port binding: %w", ErrAPIUnsupported)
+	}
+	endpoint.SwitchPortId = portID
+	return endpoint, nil
+}
+
+// ProxyARP reports whether the endpoint carries an ArpProxy policy.
+func (endpoint *HostComputeEndpoint) ProxyARP() (bool, error) {
+	for _, policy := range endpoint.Policies {
+		if policy.Type != ArpProxy {
+			continue
+		}
+		var setting ArpProxyEndpointPolicySetting
+		if err := json.Unmarshal(policy.Settings, &setting); err != nil {
+			return false, err
+		}
+		return setting.Enabled, nil
+	}
+	return false, nil
+}
+
+// GatewayMAC returns the gateway MAC override from the endpoint's GatewayMac
+// policy, or "" when none is set.
+func (endpoint *HostComputeEndpoint) GatewayMAC() (string, error) {
+	for _, policy := range endpoint.Policies {
+		if policy.Type != GatewayMac {
+			continue
+		}
+		var setting GatewayMacEndpointPolicySetting
+		if err := json.Unmarshal(policy.Settings, &setting); err != nil {
+			return "", err
+		}
+		return setting.MacAddress, nil
+	}
+	return "", nil
+}
+
+// ApplyPoliciesToEndpoints patches every endpoint in ids with the same policy
+// change: policies whose type appears in removeTypes are removed, then the
+// policies in add are added. The per-endpoint patches are issued concurrently
+// with bounded parallelism, and the result reports which endpoints succeeded
+// and which failed.
+func ApplyPoliciesToEndpoints(ids []guid.GUID, add []EndpointPolicy, removeTypes []EndpointPolicyType) BatchResult[guid.GUID] {
+	logrus.Debugf("hcn::HostComputeEndpoint::ApplyPoliciesToEndpoints count=%d", len(ids))
+
+	errs := make([]error, len(ids))
+	runBounded(len(ids), func(i int) {
+		errs[i] = applyPoliciesToEndpoint(ids[i], add, removeTypes)
+	})
+
+	var result BatchResult[guid.GUID]
+	for i, err := range errs {
+		if err != nil {
+			result.Failed = append(result.Failed, BatchFailure[guid.GUID]{Item: ids[i], Err: err})
+			continue
+		}
+		result.Succeeded = append(result.Succeeded, ids[i])
+	}
+	return result
+}
+
+func applyPoliciesToEndpoint(id guid.GUID, add []EndpointPolicy, removeTypes []EndpointPolicyType) error {
+	endpoint, err := GetEndpointByID(id.String())
+	if err != nil {
+		return err
+	}
+	var remove []EndpointPolicy
+	for _, policy := range endpoint.Policies {
+		if slices.Contains(removeTypes, policy.Type) {
+			remove = append(remove, policy)
+		}
+	}
+	if len(remove) > 0 {
+		if err := endpoint.ApplyPolicy(RequestTypeRemove, PolicyEndpointRequest{Policies: remove}); err != nil {
+			return err
+		}
+	}
+	if len(add) > 0 {
+		if err := endpoint.ApplyPolicy(RequestTypeAdd, PolicyEndpointRequest{Policies: add}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RoutingPriority returns the endpoint's routing priority, parsed from its
+// RoutingPriority policy. Endpoints without the policy report priority 0.
+func (endpoint *HostComputeEndpoint) RoutingPriority() (uint32, error) {
+	for _, policy := range endpoint.Policies {
+		if policy.Type != RoutingPriority {
+			continue
+		}
+		var setting RoutingPriorityEndpointPolicySetting
+		if err := json.Unmarshal(policy.Settings, &setting); err != nil {
+			return 0, err
+		}
+		return setting.Priority, nil
+	}
+	return 0, nil
+}
+
+// SetRoutingPriority re-tunes the endpoint's routing priority in place, so
+// failover preferences can change without recreating the endpoint.
+func (endpoint *HostComputeEndpoint) SetRoutingPriority(priority uint32) error {
+	logrus.Debugf("hcn::HostComputeEndpoint::SetRoutingPriority id=%s priority=%d", endpoint.Id, priority)
+
+	policy, err := NewRoutingPriorityEndpointPolicy(priority)
+	if err != nil {
+		return err
+	}
+	return endpoint.ApplyPolicy(RequestTypeUpdate, PolicyEndpointRequest{
+		Policies: []EndpointPolicy{*policy},
+	})
+}
+
+// Compartment returns the ID of the network compartment the Endpoint is in,
+// read from the properties of the namespace the endpoint is attached to.
+// Endpoints that are not in a namespace report compartment 0.
+func (endpoint *HostComputeEndpoint) Compartment() (uint32, error) {
+	logrus.Debugf("hcn::HostComputeEndpoint::Compartment id=%s", endpoint.Id)
+
+	// Re-query the endpoint, as the namespace association may have changed
+	// since this object was fetched.
+	ep, err := GetEndpointByID(endpoint.Id)
+	if err != nil {
+		return 0, err
+	}
+	if ep.HostComputeNamespace == "" {
+		return 0, nil
+	}
+	namespace, err := GetNamespaceByID(ep.HostComputeNamespace)
+	if err != nil {
+		return 0, err
+	}
+	return namespace.NamespaceId, nil
+}
+
+// SetCompartment moves the Endpoint into a different network compartment at
+// runtime, through the same compartment modify path ShareEndpoint uses. The
+// endpoint is added to the target compartment before it is removed from its
+// current one, so there is no window where it is reachable from neither.
+// Setting the compartment the endpoint is already in is a no-op.
+func (endpoint *HostComputeEndpoint) SetCompartment(id uint32) error {
+	logrus.Debugf("hcn::HostComputeEndpoint::SetCompartment id=%s compartment=%d", endpoint.Id, id)
+
+	current, err := endpoint.Compartment()
+	if err != nil {
+		return err
+	}
+	if current == id {
+		return nil
+	}
+
+	addJSON, err := json.Marshal(compartmentShareSetting{CompartmentId: id})
+	if err != nil {
+		return err
+	}
+	requestMessage := &ModifyEndpointSettingRequest{
+		ResourceType: EndpointResourceTypeCompartment,
+		RequestType:  RequestTypeAdd,
+		Settings:     addJSON,
+	}
+	if err := ModifyEndpointSettings(endpoint.Id, requestMessage); err != nil {
+		return err
+	}
+
+	// Endpoints that were not in a compartment have nothing to leave.
+	if current == 0 {
+		return nil
+	}
+	removeJSON, err := json.Marshal(compartmentShareSetting{CompartmentId: current})
+	if err != nil {
+		return err
+	}
+	requestMessage = &ModifyEndpointSettingRequest{
+		ResourceType: EndpointResourceTypeCompartment,
+		RequestType:  RequestTypeRemove,
+		Settings:     removeJSON,
+	}
+	return ModifyEndpointSettings(endpoint.Id, requestMessage)
+}
+
+// FlushConnections resets the connection-tracking state of the Endpoint, so
+// that flows disallowed by a policy change cannot persist on established
+// connections. HNS does not expose a per-endpoint connection flush through
+// either the V2 modify path or the legacy HNSCall API, so this currently
+// always returns ErrAPIUnsupported; callers that need prompt enforcement must
+// recreate the endpoint instead.
+func (endpoint *HostComputeEndpoint) FlushConnections() error {
+	return ErrAPIUnsupported
+}
+
+// UpdateDNS updates the DNS configuration of an Endpoint without touching its
+// IP configurations or policies. The endpoint's existing DNS options are
+// preserved. It is a no-op when the requested configuration matches the
+// endpoint's current one.
+func (endpoint *HostComputeEndpoint) UpdateDNS(servers []string, suffix string, search []string) error {
+	logrus.Debugf("hcn::HostComputeEndpoint::UpdateDNS id=%s", endpoint.Id)
+
+	for _, server := range servers {
+		if net.ParseIP(server) == nil {
+			return fmt.Errorf("endpoint DNS update error, invalid DNS server address %q", server)
+		}
+	}
+
+	if endpoint.Dns.Domain == suffix &&
+		slices.Equal(endpoint.Dns.ServerList, servers) &&
+		slices.Equal(endpoint.Dns.Search, search) {
+		return nil
+	}
+
+	dns := Dns{
+		Domain:     suffix,
+		Search:     search,
+		ServerList: servers,
+		Options:    endpoint.Dns.Options,
+	}
+	settingsJSON, err := json.Marshal(dns)
+	if err != nil {
+		return err
+	}
+	requestMessage := &ModifyEndpointSettingRequest{
+		ResourceType: EndpointResourceTypeDNS,
+		RequestType:  RequestTypeUpdate,
+		Settings:     settingsJSON,
+	}
+
+	if err := ModifyEndpointSettings(endpoint.Id, requestMessage); err != nil {
+		return err
+	}
+	endpoint.Dns = dns
+	return nil
+}
+
+// SetPacketCapture toggles HNS's per-endpoint packet capture on builds that
+// support it, applying (or on disable, removing) a PacketCapture policy.
+// Captured traffic is written to the service's ETW session and is collected
+// with the usual HNS trace tooling, not to a file this package manages. On
+// builds whose HNS does not recognize the policy, ErrAPIUnsupported is
+// returned.
+func (endpoint *HostComputeEndpoint) SetPacketCapture(enabled bool, opts CaptureOptions) error {
+	logrus.Debugf("hcn::HostComputeEndpoint::SetPacketCapture id=%s enabled=%t", endpoint.Id, enabled)
+
+	settings, err := json.Marshal(PacketCaptureEndpointPolicySetting{
+		Enabled:        enabled,
+		CaptureOptions: opts,
+	})
+	if err != nil {
+		return err
+	}
+	requestType := RequestTypeUpdate
+	if !enabled {
+		requestType = RequestTypeRemove
+	}
+	err = endpoint.ApplyPolicy(requestType, PolicyEndpointRequest{
+		Policies: []EndpointPolicy{{
+			Type:     PacketCapture,
+			Settings: settings,
+		}},
+	})
+	if IsNotImplemented(err) {
+		return fmt.Errorf("endpoint packet capture is not supported on this build: %w", ErrAPIUnsupported)
+	}
+	return err
+}
+
+// SetIPConfig replaces the endpoint's IP configurations in place via modify,
+// so a pod can be re-IPed without deleting and recreating the endpoint (and
+// losing its GUID). On builds where HNS does not support modifying the IP
+// configuration resource, ErrAPIUnsupported is returned. The call waits,
+// bounded by ctx, for the new addresses to take effect before returning.
+func (endpoint *HostComputeEndpoint) SetIPConfig(ctx context.Context, configs []IpConfig) error {
+	logrus.Debugf("hcn::HostComputeEndpoint::SetIPConfig id=%s", endpoint.Id)
+
+	if len(configs) == 0 {
+		return errors.New("at least one IP configuration is required")
+	}
+	want := make([]string, 0, len(configs))
+	for _, config := range configs {
+		addr, err := netip.ParseAddr(config.IpAddress)
+		if err != nil {
+			return fmt.Errorf("invalid IP address %q: %w", config.IpAddress, err)
+		}
+		if (addr.Is4() && config.PrefixLength > 32) || (addr.Is6() && config.PrefixLength > 128) {
+			return fmt.Errorf("invalid prefix length %d for address %q", config.PrefixLength, config.IpAddress)
+		}
+		want = append(want, addr.String())
+	}
+	slices.Sort(want)
+
+	settingsJSON, err := json.Marshal(configs)
+	if err != nil {
+		return err
+	}
+	requestMessage := &ModifyEndpointSettingRequest{
+		ResourceType: EndpointResourceTypeIPConfig,
+		RequestType:  RequestTypeUpdate,
+		Settings:     settingsJSON,
+	}
+	if err := ModifyEndpointSettings(endpoint.Id, requestMessage); err != nil {
+		if IsNotImplemented(err) {
+			return fmt.Errorf("endpoint IP configuration cannot be modified on this build: %w", ErrAPIUnsupported)
+		}
+		return err
+	}
+
+	// Wait for the service to report the new addresses on the endpoint.
+	return pollUntil(ctx, DefaultPollOptions(), func() (bool, error) {
+		current, err := GetEndpointByID(endpoint.Id)
+		if err != nil {
+			return false, err
+		}
+		have := make([]string, 0, len(current.IpConfigurations))
+		for _, config := range current.IpConfigurations {
+			have = append(have, config.IpAddress)
+		}
+		slices.Sort(have)
+		if !slices.Equal(have, want) {
+			return false, nil
+		}
+		endpoint.IpConfigurations = current.IpConfigurations
+		return true, nil
+	})
+}
+
+// NamespaceAttach modifies a Namespace to add an endpoint.
+func (endpoint *HostComputeEndpoint) NamespaceAttach(namespaceID string) error {
+	return AddNamespaceEndpoint(namespaceID, endpoint.Id)
+}
+
+// NamespaceDetach modifies a Namespace to remove an endpoint.
+func (endpoint *HostComputeEndpoint) NamespaceDetach(namespaceID string) error {
+	return RemoveNamespaceEndpoint(namespaceID, endpoint.Id)
+}
+
+// EndpointState is the lifecycle state HNS reports for an endpoint in its
+// properties document.
+type EndpointState int
+
+const (
+	EndpointStateUninitialized EndpointState = iota
+	EndpointStateCreated
+	EndpointStateAttached
+	EndpointStateAttachedSharing
+	EndpointStateDetached
+	EndpointStateDegraded
+	EndpointStateDestroyed
+)
+
+func (s EndpointState) String() string {
+	switch s {
+	case EndpointStateUninitialized:
+		return "Uninitialized"
+	case EndpointStateCreated:
+		return "Created"
+	case EndpointStateAttached:
+		return "Attached"
+	case EndpointStateAttachedSharing:
+		return "AttachedSharing"
+	case EndpointStateDetached:
+		return "Detached"
+	case EndpointStateDegraded:
+		return "Degraded"
+	case EndpointStateDestroyed:
+		return "Destroyed"
+	}
+	return fmt.Sprintf("EndpointState(%d)", int(s))
+}
+
+// WaitReady polls the endpoint's properties until HNS reports it attached —
+// Attached or AttachedSharing — or ctx expires, replacing the sleep-loops
+// callers otherwise write because a freshly created endpoint is not
+// immediately attachable. opts tunes the poll cadence; the zero value uses
+// DefaultPollOptions. The last observed state is returned alongside any
+// error, so a caller that timed out can log what HNS last reported.
+func (endpoint *HostComputeEndpoint) WaitReady(ctx context.Context, opts PollOptions) (EndpointState, error) {
+	logrus.Debugf("hcn::HostComputeEndpoint::WaitReady id=%s", endpoint.Id)
+
+	endpointGUID, err := guid.FromString(endpoint.Id)
+	if err != nil {
+		return EndpointStateUninitialized, errInvalidEndpointID
+	}
+	queryJSON, err := json.Marshal(defaultQuery())
+	if err != nil {
+		return EndpointStateUninitialized, err
+	}
+
+	var state EndpointState
+	pollErr := pollUntil(ctx, opts, func() (bool, error) {
+		properties, err := queryEndpointProperties(endpointGUID, string(queryJSON))
+		if err != nil {
+			return false, err
+		}
+		var document struct {
+			State EndpointState `json:",omitempty"`
+		}
+		if err := json.Unmarshal([]byte(properties), &document); err != nil {
+			return false, fmt.Errorf("parse endpoint %s properties: %w", endpoint.Id, err)
+		}
+		state = document.State
+		return state == EndpointStateAttached || state == EndpointStateAttachedSharing, nil
+	})
+	if pollErr != nil {
+		return state, fmt.Errorf("endpoint %s is not ready, last state %s: %w", endpoint.Id, state, pollErr)
+	}
+	return state, nil
 }
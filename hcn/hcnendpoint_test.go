@@ -4,9 +4,14 @@
 package hcn
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"net/netip"
+	"reflect"
 	"testing"
+	"time"
 )
 
 func TestCreateDeleteEndpoint(t *testing.T) {
@@ -311,6 +316,40 @@ func TestModifyEndpointSettings(t *testing.T) {
 	}
 }
 
+func TestModifyEndpointSettingsWithResultInvalidPolicy(t *testing.T) {
+	network, err := HcnCreateTestNATNetwork()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = network.Delete()
+	}()
+	endpoint, err := HcnCreateTestEndpoint(network)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = endpoint.Delete()
+	}()
+
+	requestMessage := &ModifyEndpointSettingRequest{
+		ResourceType: EndpointResourceTypePolicy,
+		RequestType:  RequestTypeUpdate,
+		Settings:     json.RawMessage(`{"Policies":[{"Type":"NoSuchPolicyType","Settings":{}}]}`),
+	}
+
+	result, err := ModifyEndpointSettingsWithResult(endpoint.Id, requestMessage)
+	if err == nil {
+		t.Fatal("expected modify with invalid policy to fail")
+	}
+	if result == "" {
+		t.Fatal("expected a non-empty result document on failure")
+	}
+	if !json.Valid([]byte(result)) {
+		t.Fatalf("result document is not valid JSON: %s", result)
+	}
+}
+
 func TestApplyTierAclPolicyOnEndpoint(t *testing.T) {
 	network, err := HcnCreateTestL2BridgeNetwork()
 	if err != nil {
@@ -363,3 +402,162 @@ func TestApplyTierAclPolicyOnEndpoint(t *testing.T) {
 		t.Fatal("No Endpoint Policies found")
 	}
 }
+
+func TestCreateEndpointContextPreCanceled(t *testing.T) {
+	network, err := HcnCreateTestNATNetwork()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer network.Delete() //nolint:errcheck
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	endpoint, _, err := CreateEndpointContext(ctx, network.Id, &HostComputeEndpoint{
+		Name:          NatTestEndpointName,
+		SchemaVersion: V2SchemaVersion(),
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatal("Expected context.Canceled error.", err)
+	}
+	if endpoint != nil {
+		_ = endpoint.Delete()
+		t.Fatal("Endpoint should not have been created.")
+	}
+
+	// The create syscall must not have run: no endpoint by that name exists.
+	if _, err := GetEndpointByName(NatTestEndpointName); !IsNotFoundError(err) {
+		t.Fatal("Endpoint was created despite pre-canceled context.", err)
+	}
+}
+
+func TestCreateEndpointContextCanceledMidWait(t *testing.T) {
+	network, err := HcnCreateTestNATNetwork()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer network.Delete() //nolint:errcheck
+
+	// A deadline this short is still live at the entry check but expires
+	// during the create syscall or the IP wait, exercising the cleanup path.
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+
+	endpoint, _, err := CreateEndpointContext(ctx, network.Id, &HostComputeEndpoint{
+		Name:          NatTestEndpointName,
+		SchemaVersion: V2SchemaVersion(),
+	})
+	if err == nil {
+		_ = endpoint.Delete()
+		t.Skip("endpoint completed before the deadline; cleanup window not exercised")
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatal("Expected context.DeadlineExceeded error.", err)
+	}
+
+	// The just-created endpoint must have been cleaned up.
+	if _, err := GetEndpointByName(NatTestEndpointName); !IsNotFoundError(err) {
+		t.Fatal("Endpoint was left behind after cancellation.", err)
+	}
+}
+
+func TestSetDNSRoundTrip(t *testing.T) {
+	network, err := HcnCreateTestNATNetwork()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = network.Delete()
+	}()
+	endpoint, err := HcnCreateTestEndpoint(network)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = endpoint.Delete()
+	}()
+
+	servers := []netip.Addr{netip.MustParseAddr("10.0.0.10"), netip.MustParseAddr("10.0.0.11")}
+	if err := endpoint.SetDNS(servers, []string{"example.com", "corp.example.com", "example.com"}); err != nil {
+		t.Fatal(err)
+	}
+
+	queried, err := GetEndpointByID(endpoint.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantServers := []string{"10.0.0.10", "10.0.0.11"}
+	if !reflect.DeepEqual(queried.Dns.ServerList, wantServers) {
+		t.Errorf("queried server list %v, want %v", queried.Dns.ServerList, wantServers)
+	}
+	wantSearch := []string{"example.com", "corp.example.com"}
+	if !reflect.DeepEqual(queried.Dns.Search, wantSearch) {
+		t.Errorf("queried search list %v, want %v", queried.Dns.Search, wantSearch)
+	}
+}
+
+func TestSetDNSRejectsInvalidServer(t *testing.T) {
+	endpoint := &HostComputeEndpoint{Id: "5f0b1190-63aa-45f4-9aa2-10b734b2eb6d"}
+	if err := endpoint.SetDNS([]netip.Addr{{}}, nil); err == nil {
+		t.Fatal("expected invalid server address to be rejected")
+	}
+	if err := endpoint.SetDNS([]netip.Addr{netip.MustParseAddr("0.0.0.0")}, nil); err == nil {
+		t.Fatal("expected unspecified server address to be rejected")
+	}
+}
+
+func TestEndpointWithMacAddress(t *testing.T) {
+	network, err := HcnCreateTestNATNetwork()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = network.Delete()
+	}()
+
+	endpoint := &HostComputeEndpoint{
+		Name: NatTestEndpointName,
+		SchemaVersion: SchemaVersion{
+			Major: 2,
+			Minor: 0,
+		},
+	}
+	if _, err := endpoint.WithMacAddress("00:15:5d:aa:bb:cc"); err != nil {
+		t.Fatal(err)
+	}
+	created, err := network.CreateEndpoint(endpoint)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = created.Delete()
+	}()
+
+	queried, err := GetEndpointByID(created.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if queried.MacAddress != "00-15-5D-AA-BB-CC" {
+		t.Errorf("queried MAC %q, want %q", queried.MacAddress, "00-15-5D-AA-BB-CC")
+	}
+}
+
+func TestWithMacAddressValidation(t *testing.T) {
+	endpoint := &HostComputeEndpoint{}
+	for _, mac := range []string{
+		"not-a-mac",
+		"01-00-5e-00-00-01",       // multicast
+		"ff-ff-ff-ff-ff-ff",       // broadcast
+		"02-00-00-00-00-00-00-01", // EUI-64, not 6 octets
+	} {
+		if _, err := endpoint.WithMacAddress(mac); err == nil {
+			t.Errorf("expected MAC %q to be rejected", mac)
+		}
+	}
+	if _, err := endpoint.WithMacAddress("00:15:5d:aa:bb:cc"); err != nil {
+		t.Fatal(err)
+	}
+	if endpoint.MacAddress != "00-15-5D-AA-BB-CC" {
+		t.Errorf("stored MAC %q, want normalized %q", endpoint.MacAddress, "00-15-5D-AA-BB-CC")
+	}
+}
@@ -5,13 +5,46 @@ package hcn
 import (
 	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/sirupsen/logrus"
 	"golang.org/x/sys/windows"
 
 	"github.com/Microsoft/hcsshim/internal/hcs"
 	"github.com/Microsoft/hcsshim/internal/hcserror"
-	"github.com/Microsoft/hcsshim/internal/interop"
+)
+
+// ErrAPIUnsupported is returned when an operation has no supported HCN API on
+// the running OS build or schema version.
+var ErrAPIUnsupported = errors.New("HCN API is not supported")
+
+// ErrInvalidGUID is returned when a GUID string argument cannot be parsed,
+// with or without braces and in any case.
+var ErrInvalidGUID = errors.New("invalid GUID")
+
+// ErrInvalidSettings is returned when a settings document cannot be passed to
+// the HCN service, for example because it contains an embedded NUL character.
+var ErrInvalidSettings = errors.New("invalid settings document")
+
+// ErrNetworkInUse is returned when a network cannot be deleted because other
+// objects still depend on it.
+var ErrNetworkInUse = errors.New("network is in use")
+
+// ErrStatisticsUnavailable is returned by Statistics when the host did not
+// populate traffic counters for the endpoint.
+var ErrStatisticsUnavailable = errors.New("endpoint statistics unavailable")
+
+// Sentinels for the common HCN failure codes. HcnError maps its HRESULT onto
+// these in its Is method, so callers can test for a class of failure with
+// errors.Is instead of comparing HRESULT values.
+var (
+	// ErrNetworkNotFound matches failures where the network does not exist.
+	ErrNetworkNotFound = errors.New("network not found")
+	// ErrEndpointNotFound matches failures where the endpoint does not exist.
+	ErrEndpointNotFound = errors.New("endpoint not found")
+	// ErrNetworkAlreadyExists matches creates that collided with an existing
+	// network.
+	ErrNetworkAlreadyExists = errors.New("network already exists")
 )
 
 var (
@@ -22,19 +55,49 @@ var (
 	errInvalidRouteID        = errors.New("invalid route ID")
 )
 
-func checkForErrors(methodName string, hr error, resultBuffer *uint16) error {
+// checkSettings rejects settings payloads the syscall layer cannot convert.
+// syscall.UTF16PtrFromString fails on an embedded NUL byte with no context
+// about what was being converted, so catch it up front and report which
+// operation was handed the bad payload.
+func checkSettings(methodName string, settings string) error {
+	if strings.ContainsRune(settings, 0) {
+		return fmt.Errorf("%s: settings contain an embedded NUL character: %w", methodName, ErrInvalidSettings)
+	}
+	return nil
+}
+
+// checkForErrors inspects the outcome of an HCN call. It always consumes and
+// frees *resultBuffer when HNS allocated one, on success and failure alike,
+// and clears the caller's pointer so a stale buffer is never freed twice when
+// the same variable is reused across calls.
+func checkForErrors(methodName string, hr error, resultBuffer **uint16) error {
+	result := ""
+	if resultBuffer != nil && *resultBuffer != nil {
+		var decodeErr error
+		result, decodeErr = decodeAndFree(*resultBuffer)
+		*resultBuffer = nil
+		if decodeErr != nil {
+			// The buffer is freed regardless; a result document we cannot
+			// decode should not mask the call's own outcome.
+			logrus.Debugf("hcn::checkForErrors failed to decode result document: %s", decodeErr)
+		}
+	}
+
+	// A failure on a host without the containers feature is a missing-DLL
+	// error from every procedure; report it as the one well-defined
+	// availability error instead.
+	if hr != nil && !Available() {
+		return fmt.Errorf("%s: %w", methodName, ErrHCNNotAvailable)
+	}
+
 	errorFound := false
 
 	if hr != nil {
 		errorFound = true
 	}
 
-	result := ""
-	if resultBuffer != nil {
-		result = interop.ConvertAndFreeCoTaskMemString(resultBuffer)
-		if result != "" {
-			errorFound = true
-		}
+	if result != "" {
+		errorFound = true
 	}
 
 	if errorFound {
@@ -50,20 +113,74 @@ type ErrorCode uint32
 
 // For common errors, define the error as it is in windows, so we can quickly determine it later
 const (
-	ERROR_NOT_FOUND                     = ErrorCode(windows.ERROR_NOT_FOUND)
-	HCN_E_PORT_ALREADY_EXISTS ErrorCode = ErrorCode(windows.HCN_E_PORT_ALREADY_EXISTS)
-	HCN_E_NOTIMPL             ErrorCode = ErrorCode(windows.E_NOTIMPL)
+	ERROR_NOT_FOUND                          = ErrorCode(windows.ERROR_NOT_FOUND)
+	HCN_E_PORT_ALREADY_EXISTS      ErrorCode = ErrorCode(windows.HCN_E_PORT_ALREADY_EXISTS)
+	HCN_E_NOTIMPL                  ErrorCode = ErrorCode(windows.E_NOTIMPL)
+	HCN_E_INVALID_IP               ErrorCode = ErrorCode(windows.HCN_E_INVALID_IP)
+	HCN_E_ADDR_INVALID_OR_RESERVED ErrorCode = ErrorCode(windows.HCN_E_ADDR_INVALID_OR_RESERVED)
+	HCN_E_NETWORK_NOT_FOUND        ErrorCode = ErrorCode(windows.HCN_E_NETWORK_NOT_FOUND)
+	HCN_E_ENDPOINT_NOT_FOUND       ErrorCode = ErrorCode(windows.HCN_E_ENDPOINT_NOT_FOUND)
+	HCN_E_NETWORK_ALREADY_EXISTS   ErrorCode = ErrorCode(windows.HCN_E_NETWORK_ALREADY_EXISTS)
 )
 
 type HcnError struct {
 	*hcserror.HcsError
 	code ErrorCode
+	// Op is the HCN procedure that failed, e.g. "hcnCreateEndpoint".
+	Op string
+	// Result is the JSON diagnostic document HCN returned alongside the
+	// failure, describing what it rejected. Empty when HCN provided none.
+	Result string
 }
 
 func (e *HcnError) Error() string {
 	return e.HcsError.Error()
 }
 
+// Is maps the common HCN failure codes onto the package's sentinel errors, so
+// callers can classify failures with errors.Is instead of comparing HRESULT
+// values. HNS reports missing objects either with the object-specific HCN
+// code or with the generic ERROR_NOT_FOUND depending on the call, so the
+// not-found sentinels match both.
+func (e *HcnError) Is(target error) bool {
+	switch target {
+	case ErrNetworkNotFound:
+		return e.code == HCN_E_NETWORK_NOT_FOUND || e.code == ERROR_NOT_FOUND
+	case ErrEndpointNotFound:
+		return e.code == HCN_E_ENDPOINT_NOT_FOUND || e.code == ERROR_NOT_FOUND
+	case ErrNetworkAlreadyExists:
+		return e.code == HCN_E_NETWORK_ALREADY_EXISTS
+	}
+	return false
+}
+
+// HResult returns the full HRESULT of the failure that produced e. The
+// generated syscall layer masks FACILITY_WIN32 HRESULTs down to their bare
+// Win32 code before the rest of the package sees them; HResult restores the
+// conventional 0x8007xxxx form for those and returns every other code
+// unchanged, so callers that need the original HRESULT are not limited to
+// the masked value. The masked code remains what the Is*Error helpers and
+// CheckErrorWithCode compare against.
+func (e *HcnError) HResult() uint32 {
+	code := uint32(e.code)
+	if code != 0 && code&0xffff0000 == 0 {
+		return 0x80070000 | code
+	}
+	return code
+}
+
+// ResultDocument extracts the JSON diagnostic document HCN attached to err,
+// or "" if err carries none. Every failure that came out of an HCN call
+// carries the document on its HcnError, so this works on wrapped errors from
+// any of the public functions.
+func ResultDocument(err error) string {
+	var hcnError *HcnError
+	if errors.As(err, &hcnError) {
+		return hcnError.Result
+	}
+	return ""
+}
+
 func CheckErrorWithCode(err error, code ErrorCode) bool {
 	var hcnError *HcnError
 	if errors.As(err, &hcnError) {
@@ -80,12 +197,26 @@ func IsPortAlreadyExistsError(err error) bool {
 	return CheckErrorWithCode(err, HCN_E_PORT_ALREADY_EXISTS)
 }
 
+// IsAlreadyExistsError returns a boolean indicating whether the error was
+// caused by the object already existing.
+func IsAlreadyExistsError(err error) bool {
+	return CheckErrorWithCode(err, HCN_E_NETWORK_ALREADY_EXISTS) ||
+		CheckErrorWithCode(err, ErrorCode(windows.ERROR_OBJECT_ALREADY_EXISTS)) ||
+		CheckErrorWithCode(err, ErrorCode(windows.ERROR_ALREADY_EXISTS))
+}
+
 func IsNotImplemented(err error) bool {
 	return CheckErrorWithCode(err, HCN_E_NOTIMPL)
 }
 
+// IsAddressConflictError returns true when the error indicates a requested IP
+// address was invalid, reserved, or already in use.
+func IsAddressConflictError(err error) bool {
+	return CheckErrorWithCode(err, HCN_E_INVALID_IP) || CheckErrorWithCode(err, HCN_E_ADDR_INVALID_OR_RESERVED)
+}
+
 func new(hr error, title string, rest string) error {
-	err := &HcnError{}
+	err := &HcnError{Op: title, Result: rest}
 	hcsError := hcserror.New(hr, title, rest)
 	err.HcsError = hcsError.(*hcserror.HcsError) //nolint:errorlint
 	err.code = ErrorCode(hcserror.Win32FromError(hr))
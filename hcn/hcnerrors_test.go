@@ -5,7 +5,9 @@ package hcn
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/Microsoft/go-winio/pkg/guid"
@@ -100,6 +102,19 @@ func TestEndpointAlreadyExistsError(t *testing.T) {
 	}
 }
 
+func TestSettingsWithEmbeddedNUL(t *testing.T) {
+	_, err := createNetwork("{\"Name\":\"nul\x00network\"}")
+	if err == nil {
+		t.Fatal("Error was not thrown.")
+	}
+	if !errors.Is(err, ErrInvalidSettings) {
+		t.Fatal("Error is not an ErrInvalidSettings Error.", err)
+	}
+	if !strings.Contains(err.Error(), "hcnCreateNetwork") {
+		t.Fatal("Error does not identify the operation.", err)
+	}
+}
+
 func TestNotFoundError(t *testing.T) {
 	namespaceGuid, _ := guid.FromString("5f0b1190-63be-4e0c-b974-bd0f55675a42")
 	_, err := getNamespace(namespaceGuid, "")
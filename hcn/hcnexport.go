@@ -0,0 +1,88 @@
+//go:build windows
+
+package hcn
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/Microsoft/go-winio/pkg/guid"
+	"github.com/sirupsen/logrus"
+)
+
+// NetworkExport is a portable capture of a network's full state, including the
+// endpoints attached to it and all of their policies. It is produced by
+// ExportNetwork and consumed by ImportNetwork.
+type NetworkExport struct {
+	Network   HostComputeNetwork    `json:",omitempty"`
+	Endpoints []HostComputeEndpoint `json:",omitempty"`
+}
+
+// ExportNetwork serializes the complete state of the network specified by id,
+// including its endpoints and policies, to portable JSON suitable for
+// ImportNetwork on another host.
+func ExportNetwork(id guid.GUID) ([]byte, error) {
+	logrus.Debugf("hcn::ExportNetwork id=%s", id)
+
+	network, err := GetNetworkByID(id.String())
+	if err != nil {
+		return nil, err
+	}
+	endpoints, err := ListEndpointsOfNetwork(network.Id)
+	if err != nil {
+		return nil, err
+	}
+	export := NetworkExport{
+		Network:   *network,
+		Endpoints: endpoints,
+	}
+	return json.Marshal(export)
+}
+
+// ImportNetwork recreates a network captured by ExportNetwork, along with its
+// endpoints and policies. HNS assigns fresh GUIDs to the recreated objects;
+// the returned map records the remapping from exported GUID to new GUID for
+// the network and every endpoint. If an endpoint fails to create, the
+// partially imported network is deleted before returning.
+//
+// State that is host-specific cannot be preserved across hosts: MAC addresses
+// drawn from the host's pool, namespace and compartment associations, switch
+// port bindings, and health/runtime state are all re-established by HNS on the
+// importing host.
+func ImportNetwork(data []byte) (*HostComputeNetwork, map[string]string, error) {
+	var export NetworkExport
+	if err := json.Unmarshal(data, &export); err != nil {
+		return nil, nil, err
+	}
+	logrus.Debugf("hcn::ImportNetwork name=%s", export.Network.Name)
+
+	mapping := make(map[string]string)
+
+	network := export.Network
+	oldNetworkID := network.Id
+	network.Id = ""
+	network.Health = Health{}
+	newNetwork, err := network.Create()
+	if err != nil {
+		return nil, nil, err
+	}
+	mapping[oldNetworkID] = newNetwork.Id
+
+	for _, endpoint := range export.Endpoints {
+		oldEndpointID := endpoint.Id
+		endpoint.Id = ""
+		endpoint.HostComputeNamespace = ""
+		endpoint.Health = Health{}
+		newEndpoint, err := newNetwork.CreateEndpoint(&endpoint)
+		if err != nil {
+			// Deleting the network also removes any endpoints created so far.
+			if deleteErr := newNetwork.Delete(); deleteErr != nil {
+				logrus.WithError(deleteErr).Debugf("hcn::ImportNetwork failed to clean up network %s", newNetwork.Id)
+			}
+			return nil, nil, fmt.Errorf("import of endpoint %s failed: %w", oldEndpointID, err)
+		}
+		mapping[oldEndpointID] = newEndpoint.Id
+	}
+
+	return newNetwork, mapping, nil
+}
@@ -3,12 +3,12 @@
 package hcn
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"math"
 
 	"github.com/Microsoft/hcsshim/internal/hcserror"
-	"github.com/Microsoft/hcsshim/internal/interop"
 	"github.com/sirupsen/logrus"
 )
 
@@ -30,11 +30,23 @@ type VersionRange struct {
 
 type VersionRanges []VersionRange
 
+// AtLeast reports whether v is version major.minor or newer.
+func (v Version) AtLeast(major, minor int) bool {
+	if v.Major != major {
+		return v.Major > major
+	}
+	return v.Minor >= minor
+}
+
 var (
 	// HNSVersion1803 added ACL functionality.
 	HNSVersion1803 = VersionRanges{VersionRange{MinVersion: Version{Major: 7, Minor: 2}, MaxVersion: Version{Major: math.MaxInt32, Minor: math.MaxInt32}}}
 	// V2ApiSupport allows the use of V2 Api calls and V2 Schema.
 	V2ApiSupport = VersionRanges{VersionRange{MinVersion: Version{Major: 9, Minor: 2}, MaxVersion: Version{Major: math.MaxInt32, Minor: math.MaxInt32}}}
+	// Schema21Version is the HNS range that accepts schema version 2.1 documents.
+	Schema21Version = VersionRanges{VersionRange{MinVersion: Version{Major: 11, Minor: 0}, MaxVersion: Version{Major: math.MaxInt32, Minor: math.MaxInt32}}}
+	// Schema22Version is the HNS range that accepts schema version 2.2 documents.
+	Schema22Version = VersionRanges{VersionRange{MinVersion: Version{Major: 12, Minor: 0}, MaxVersion: Version{Major: math.MaxInt32, Minor: math.MaxInt32}}}
 	// Remote Subnet allows for Remote Subnet policies on Overlay networks
 	RemoteSubnetVersion = VersionRanges{VersionRange{MinVersion: Version{Major: 9, Minor: 2}, MaxVersion: Version{Major: math.MaxInt32, Minor: math.MaxInt32}}}
 	// A Host Route policy allows for local container to local host communication Overlay networks
@@ -91,6 +103,9 @@ var (
 	ModifyLoadbalancerVersion = VersionRanges{VersionRange{MinVersion: Version{Major: 15, Minor: 4}, MaxVersion: Version{Major: math.MaxInt32, Minor: math.MaxInt32}}}
 	// HNS 15.4 allows for Accelnet support
 	AccelnetVersion = VersionRanges{VersionRange{MinVersion: Version{Major: 15, Minor: 4}, MaxVersion: Version{Major: math.MaxInt32, Minor: math.MaxInt32}}}
+	// HNS 15.4 (Windows Server 2025, build 26100) allows confidential/isolated
+	// networking flags on networks and endpoints
+	ConfidentialNetworkingVersion = VersionRanges{VersionRange{MinVersion: Version{Major: 15, Minor: 4}, MaxVersion: Version{Major: math.MaxInt32, Minor: math.MaxInt32}}}
 )
 
 // GetGlobals returns the global properties of the HCN Service.
@@ -122,7 +137,10 @@ func hnsCall(method, path, request string, returnResponse interface{}) error {
 	if err != nil {
 		return hcserror.New(err, "hnsCall", "")
 	}
-	response := interop.ConvertAndFreeCoTaskMemString(responseBuffer)
+	response, err := decodeAndFree(responseBuffer)
+	if err != nil {
+		return err
+	}
 
 	hnsresponse := &hnsResponse{}
 	if err = json.Unmarshal([]byte(response), &hnsresponse); err != nil {
@@ -145,3 +163,45 @@ func hnsCall(method, path, request string, returnResponse interface{}) error {
 
 	return nil
 }
+
+// HNSRequest issues one legacy HNS API call with typed request and response
+// bodies: the body is marshaled to JSON, the HNS success/error envelope is
+// parsed, and the envelope's output is unmarshaled into TResp. A TReq of
+// string is passed through as the raw request document; use "" for calls
+// that take no body, such as GETs. This replaces the per-caller
+// marshal/unmarshal boilerplate around hnsCall.
+func HNSRequest[TReq, TResp any](method, path string, body TReq) (TResp, error) {
+	var response TResp
+	request := ""
+	switch b := any(body).(type) {
+	case string:
+		request = b
+	default:
+		requestJSON, err := json.Marshal(body)
+		if err != nil {
+			return response, fmt.Errorf("marshal HNS request body: %w", err)
+		}
+		request = string(requestJSON)
+	}
+	if err := hnsCall(method, path, request, &response); err != nil {
+		return response, err
+	}
+	return response, nil
+}
+
+// HNSCallContext issues one legacy HNS call bounded by ctx and returns the
+// response envelope's output document as a string ("" when the call produced
+// none). The underlying RPC cannot be aborted once issued: if HNS is wedged,
+// the call is left to finish — and its response to be discarded — on a
+// background goroutine, while the caller is unblocked with ctx's error,
+// context.DeadlineExceeded on timeout. The service-side operation may
+// therefore still complete after this returns.
+func HNSCallContext(ctx context.Context, method, path, body string) (string, error) {
+	return awaitContext(ctx, func() (string, error) {
+		var output json.RawMessage
+		if err := hnsCall(method, path, body, &output); err != nil {
+			return "", err
+		}
+		return string(output), nil
+	}, nil)
+}
@@ -0,0 +1,69 @@
+//go:build windows
+
+package hcn
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/Microsoft/go-winio/pkg/guid"
+)
+
+// decodeGUIDList decodes an enumeration response — a JSON array of GUID
+// strings — into parsed GUIDs. A malformed entry produces an error naming
+// the offending string, which is more actionable than the bare parse error
+// when HNS returns something unexpected.
+func decodeGUIDList(document string) ([]guid.GUID, error) {
+	var ids []string
+	if err := json.Unmarshal([]byte(document), &ids); err != nil {
+		return nil, fmt.Errorf("decode GUID list: %w", err)
+	}
+	guids := make([]guid.GUID, 0, len(ids))
+	for _, id := range ids {
+		g, err := guidFromHNSString(id)
+		if err != nil {
+			return nil, fmt.Errorf("enumeration returned malformed GUID %q: %w", id, err)
+		}
+		guids = append(guids, g)
+	}
+	return guids, nil
+}
+
+// normalizeGUIDString validates and normalizes a GUID string taken at a public
+// API boundary. GUIDs are accepted with or without braces and in any case, and
+// are normalized to the lowercase unbraced form HNS filters expect. Invalid
+// input produces an error wrapping ErrInvalidGUID that names the offending
+// argument.
+func normalizeGUIDString(argName string, value string) (string, error) {
+	g, err := parseGUIDString(value)
+	if err != nil {
+		return "", fmt.Errorf("%w %q for %s", ErrInvalidGUID, value, argName)
+	}
+	return g.String(), nil
+}
+
+// parseGUIDString parses a GUID string with or without braces, in any case.
+func parseGUIDString(value string) (guid.GUID, error) {
+	trimmed := strings.TrimSpace(value)
+	if strings.HasPrefix(trimmed, "{") && strings.HasSuffix(trimmed, "}") {
+		trimmed = trimmed[1 : len(trimmed)-1]
+	}
+	return guid.FromString(trimmed)
+}
+
+// guidToHNSString renders a GUID exactly as HNS emits it in JSON: uppercase
+// hex, unbraced. String comparisons against HNS-derived identifiers must use
+// this form (or parse both sides), since guid.GUID.String produces lowercase
+// and a bare comparison silently never matches.
+func guidToHNSString(g guid.GUID) string {
+	return strings.ToUpper(g.String())
+}
+
+// guidFromHNSString parses a GUID in any of the forms HNS has been observed
+// to emit: uppercase or lowercase, with or without braces. It is the inverse
+// of guidToHNSString and the one parser HNS-derived identifiers should go
+// through, so equality checks on the parsed values are reliable.
+func guidFromHNSString(value string) (guid.GUID, error) {
+	return parseGUIDString(value)
+}
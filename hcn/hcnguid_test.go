@@ -0,0 +1,59 @@
+//go:build windows && integration
+// +build windows,integration
+
+package hcn
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Microsoft/go-winio/pkg/guid"
+)
+
+func TestGuidFromHNSStringVariants(t *testing.T) {
+	want, err := guid.FromString("6fd70ac2-22a1-4db9-b82e-6a8e3bb2b78f")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// All forms HNS has been observed to emit must parse to the same GUID.
+	variants := []string{
+		"6fd70ac2-22a1-4db9-b82e-6a8e3bb2b78f",
+		"6FD70AC2-22A1-4DB9-B82E-6A8E3BB2B78F",
+		"{6fd70ac2-22a1-4db9-b82e-6a8e3bb2b78f}",
+		"{6FD70AC2-22A1-4DB9-B82E-6A8E3BB2B78F}",
+	}
+	for _, variant := range variants {
+		got, err := guidFromHNSString(variant)
+		if err != nil {
+			t.Fatalf("guidFromHNSString(%q) failed: %v", variant, err)
+		}
+		if got != want {
+			t.Fatalf("guidFromHNSString(%q) = %s, want %s", variant, got, want)
+		}
+	}
+
+	if _, err := guidFromHNSString("not-a-guid"); err == nil {
+		t.Fatal("expected an error for invalid input")
+	}
+}
+
+func TestGuidToHNSStringRoundTrip(t *testing.T) {
+	original, err := guid.FromString("6fd70ac2-22a1-4db9-b82e-6a8e3bb2b78f")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rendered := guidToHNSString(original)
+	if rendered != strings.ToUpper(original.String()) {
+		t.Fatalf("guidToHNSString(%s) = %q, want uppercase unbraced form", original, rendered)
+	}
+
+	parsed, err := guidFromHNSString(rendered)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed != original {
+		t.Fatalf("round-trip produced %s, want %s", parsed, original)
+	}
+}
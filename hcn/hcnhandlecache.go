@@ -0,0 +1,101 @@
+//go:build windows
+
+package hcn
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/Microsoft/go-winio/pkg/guid"
+	"github.com/sirupsen/logrus"
+)
+
+// HandleCache reference-counts open network handles by GUID, so a burst of
+// operations against the same network shares one hcnOpenNetwork call instead
+// of opening and closing per operation. The handle is closed only when the
+// count for its GUID drops to zero, which also removes the double-close
+// hazard of several goroutines owning the same raw handle. The zero value is
+// not usable; create one with NewHandleCache. All methods are safe for
+// concurrent use.
+type HandleCache struct {
+	mu      sync.Mutex
+	entries map[guid.GUID]*cachedHandle
+}
+
+type cachedHandle struct {
+	handle hcnNetwork
+	refs   int
+}
+
+// NewHandleCache returns an empty handle cache.
+func NewHandleCache() *HandleCache {
+	return &HandleCache{entries: map[guid.GUID]*cachedHandle{}}
+}
+
+// Acquire returns an open handle to the network, opening it on first use and
+// otherwise handing back the cached handle with its reference count bumped.
+// Every successful Acquire must be paired with a Release of the same GUID;
+// the handle must not be used after that Release.
+func (c *HandleCache) Acquire(id guid.GUID) (hcnNetwork, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if entry, ok := c.entries[id]; ok {
+		entry.refs++
+		return entry.handle, nil
+	}
+	handle, err := openNetworkHandle(id)
+	if err != nil {
+		return 0, err
+	}
+	logrus.Debugf("hcn::HandleCache::Acquire opened network %s", id)
+	c.entries[id] = &cachedHandle{handle: handle, refs: 1}
+	return handle, nil
+}
+
+// Release drops one reference to the network's handle and closes it when no
+// references remain. Releasing a GUID the cache does not hold is an error,
+// since it indicates an Acquire/Release imbalance that would otherwise close
+// a handle still in use.
+func (c *HandleCache) Release(id guid.GUID) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[id]
+	if !ok {
+		return fmt.Errorf("network %s is not held by the handle cache", id)
+	}
+	entry.refs--
+	if entry.refs > 0 {
+		return nil
+	}
+	delete(c.entries, id)
+	logrus.Debugf("hcn::HandleCache::Release closing network %s", id)
+	return closeNetworkHandle(entry.handle)
+}
+
+// openNetworkHandle opens the network and returns the raw handle, leaving it
+// to the caller to close; the wrappers in this package that open, use, and
+// close in one sequence do not go through here.
+func openNetworkHandle(networkGUID guid.GUID) (hcnNetwork, error) {
+	defer acquireSyscallSlot()()
+	var (
+		networkHandle hcnNetwork
+		resultBuffer  *uint16
+	)
+	callStart := traceStart()
+	hr := hcnOpenNetwork(&networkGUID, &networkHandle, &resultBuffer)
+	if err := traceEnd("hcnOpenNetwork", callStart, checkForErrors("hcnOpenNetwork", hr, &resultBuffer)); err != nil {
+		return 0, err
+	}
+	return networkHandle, nil
+}
+
+// closeNetworkHandle closes a handle obtained from openNetworkHandle.
+func closeNetworkHandle(networkHandle hcnNetwork) error {
+	defer acquireSyscallSlot()()
+	callStart := traceStart()
+	hr := hcnCloseNetwork(networkHandle)
+	if err := traceEnd("hcnCloseNetwork", callStart, checkForErrors("hcnCloseNetwork", hr, nil)); err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+//go:build windows
+
+package hcn
+
+import (
+	"errors"
+	"sync"
+
+	"golang.org/x/sys/windows"
+)
+
+var (
+	initOnce sync.Once
+	initErr  error
+)
+
+// allProcs lists every lazy procedure this package binds, so Init can force
+// resolution of each one up front.
+var allProcs = []*windows.LazyProc{
+	procHcnCloseEndpoint,
+	procHcnCloseLoadBalancer,
+	procHcnCloseNamespace,
+	procHcnCloseNetwork,
+	procHcnCloseSdnRoute,
+	procHcnCreateEndpoint,
+	procHcnCreateLoadBalancer,
+	procHcnCreateNamespace,
+	procHcnCreateNetwork,
+	procHcnCreateSdnRoute,
+	procHcnDeleteEndpoint,
+	procHcnDeleteLoadBalancer,
+	procHcnDeleteNamespace,
+	procHcnDeleteNetwork,
+	procHcnDeleteSdnRoute,
+	procHcnEnumerateEndpoints,
+	procHcnEnumerateLoadBalancers,
+	procHcnEnumerateNamespaces,
+	procHcnEnumerateNetworks,
+	procHcnEnumerateSdnRoutes,
+	procHcnModifyEndpoint,
+	procHcnModifyLoadBalancer,
+	procHcnModifyNamespace,
+	procHcnModifyNetwork,
+	procHcnModifySdnRoute,
+	procHcnOpenEndpoint,
+	procHcnOpenLoadBalancer,
+	procHcnOpenNamespace,
+	procHcnOpenNetwork,
+	procHcnOpenSdnRoute,
+	procHcnQueryEndpointProperties,
+	procHcnQueryLoadBalancerProperties,
+	procHcnQueryNamespaceProperties,
+	procHcnQueryNetworkProperties,
+	procHcnQuerySdnRouteProperties,
+	procGetCurrentThreadCompartmentId,
+	procSetCurrentThreadCompartmentId,
+	procHNSCall,
+}
+
+// Init forces resolution of every DLL procedure this package binds. Without
+// it each procedure is resolved lazily on first use, which adds latency to
+// the first call and, when many goroutines make their first call at once,
+// repeats the resolution work on each of them. Call Init once at startup to
+// front-load that cost.
+//
+// The returned error joins one error per procedure that could not be
+// resolved, so it doubles as feature detection: procedures missing on older
+// OS builds (for example the SDN route exports) are reported by name without
+// making Init fail the rest. A nil return means every procedure resolved.
+func Init() error {
+	initOnce.Do(func() {
+		var errs []error
+		for _, proc := range allProcs {
+			if err := proc.Find(); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		initErr = errors.Join(errs...)
+	})
+	return initErr
+}
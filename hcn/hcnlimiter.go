@@ -0,0 +1,60 @@
+//go:build windows
+
+package hcn
+
+import (
+	"context"
+	"sync"
+)
+
+// syscallLimiter bounds how many Hcn* syscalls are in flight at once. HNS
+// serializes heavily internally, and under high churn hundreds of concurrent
+// calls make it time out; a cap smooths the load instead. nil means
+// unlimited.
+var (
+	syscallLimiterMu sync.RWMutex
+	syscallLimiter   chan struct{}
+)
+
+// SetMaxConcurrency caps the number of HCN syscalls this package will have in
+// flight at once. Zero or negative removes the cap, which is the default.
+// Calls already in flight when the cap changes complete under the limiter
+// they acquired.
+func SetMaxConcurrency(n int) {
+	syscallLimiterMu.Lock()
+	defer syscallLimiterMu.Unlock()
+	if n <= 0 {
+		syscallLimiter = nil
+		return
+	}
+	syscallLimiter = make(chan struct{}, n)
+}
+
+// acquireSyscallSlotContext blocks until a syscall slot is free or ctx is
+// done, so a cancelled caller never holds a slot. The returned release must
+// be called when the syscall sequence finishes; it is non-nil even when no
+// limit is set.
+func acquireSyscallSlotContext(ctx context.Context) (func(), error) {
+	syscallLimiterMu.RLock()
+	limiter := syscallLimiter
+	syscallLimiterMu.RUnlock()
+	if limiter == nil {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		return func() {}, nil
+	}
+	select {
+	case limiter <- struct{}{}:
+		return func() { <-limiter }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// acquireSyscallSlot is acquireSyscallSlotContext for call paths that carry
+// no context; it blocks until a slot is free.
+func acquireSyscallSlot() func() {
+	release, _ := acquireSyscallSlotContext(context.Background())
+	return release
+}
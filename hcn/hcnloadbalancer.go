@@ -4,12 +4,29 @@ package hcn
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
+	"net/netip"
+	"sync"
 
 	"github.com/Microsoft/go-winio/pkg/guid"
-	"github.com/Microsoft/hcsshim/internal/interop"
 	"github.com/sirupsen/logrus"
 )
 
+// PortRange is a contiguous inclusive range of ports.
+type PortRange struct {
+	Start uint16 `json:",omitempty"`
+	End   uint16 `json:",omitempty"`
+}
+
+// validate rejects ranges whose start exceeds their end.
+func (r *PortRange) validate() error {
+	if r.Start > r.End {
+		return fmt.Errorf("invalid port range %d-%d: start exceeds end", r.Start, r.End)
+	}
+	return nil
+}
+
 // LoadBalancerPortMapping is associated with HostComputeLoadBalancer
 type LoadBalancerPortMapping struct {
 	Protocol         uint32                       `json:",omitempty"` // EX: TCP = 6, UDP = 17
@@ -17,6 +34,30 @@ type LoadBalancerPortMapping struct {
 	ExternalPort     uint16                       `json:",omitempty"`
 	DistributionType LoadBalancerDistribution     `json:",omitempty"` // EX: Distribute per connection = 0, distribute traffic of the same protocol per client IP = 1, distribute per client IP = 2
 	Flags            LoadBalancerPortMappingFlags `json:",omitempty"`
+	// FrontendPortRange maps a contiguous frontend port range in a single
+	// mapping on builds whose HNS supports ranges; on older builds use
+	// AddLoadBalancerPortRange, which expands the range into discrete
+	// mappings instead.
+	FrontendPortRange *PortRange `json:",omitempty"`
+	// BackendPortRange is the backend counterpart of FrontendPortRange and
+	// must cover the same number of ports.
+	BackendPortRange *PortRange `json:",omitempty"`
+}
+
+// LoadBalancerHealthProbe configures active health probing of a load
+// balancer's backends. Health probes are part of HCN schema 2.2 and later;
+// Create refuses to send them with an older schema version, since hosts that
+// cannot parse the fields fail the whole document.
+type LoadBalancerHealthProbe struct {
+	Protocol uint32 `json:",omitempty"` // EX: TCP = 6, UDP = 17
+	// IntervalInSeconds is the time between probes.
+	IntervalInSeconds uint32 `json:",omitempty"`
+	// TimeoutInSeconds is how long a probe may take before it counts as
+	// failed.
+	TimeoutInSeconds uint32 `json:",omitempty"`
+	// UnhealthyThreshold is the number of consecutive failed probes after
+	// which a backend is taken out of rotation.
+	UnhealthyThreshold uint32 `json:",omitempty"`
 }
 
 // HostComputeLoadBalancer represents software load balancer.
@@ -28,6 +69,9 @@ type HostComputeLoadBalancer struct {
 	PortMappings         []LoadBalancerPortMapping `json:",omitempty"`
 	SchemaVersion        SchemaVersion             `json:",omitempty"`
 	Flags                LoadBalancerFlags         `json:",omitempty"` // 0: None, 1: EnableDirectServerReturn
+	// HealthProbe, when set, enables active backend health probing.
+	// Requires schema version 2.2 or later.
+	HealthProbe *LoadBalancerHealthProbe `json:",omitempty"`
 }
 
 // LoadBalancerFlags modify settings for a loadbalancer.
@@ -70,25 +114,32 @@ var (
 )
 
 func getLoadBalancer(loadBalancerGUID guid.GUID, query string) (*HostComputeLoadBalancer, error) {
+	defer acquireSyscallSlot()()
 	// Open loadBalancer.
 	var (
 		loadBalancerHandle hcnLoadBalancer
 		resultBuffer       *uint16
 		propertiesBuffer   *uint16
 	)
+	callStart := traceStart()
 	hr := hcnOpenLoadBalancer(&loadBalancerGUID, &loadBalancerHandle, &resultBuffer)
-	if err := checkForErrors("hcnOpenLoadBalancer", hr, resultBuffer); err != nil {
+	if err := traceEnd("hcnOpenLoadBalancer", callStart, checkForErrors("hcnOpenLoadBalancer", hr, &resultBuffer)); err != nil {
 		return nil, err
 	}
 	// Query loadBalancer.
+	callStart = traceStart()
 	hr = hcnQueryLoadBalancerProperties(loadBalancerHandle, query, &propertiesBuffer, &resultBuffer)
-	if err := checkForErrors("hcnQueryLoadBalancerProperties", hr, resultBuffer); err != nil {
+	if err := traceEnd("hcnQueryLoadBalancerProperties", callStart, checkForErrors("hcnQueryLoadBalancerProperties", hr, &resultBuffer)); err != nil {
+		return nil, err
+	}
+	properties, err := decodeAndFree(propertiesBuffer)
+	if err != nil {
 		return nil, err
 	}
-	properties := interop.ConvertAndFreeCoTaskMemString(propertiesBuffer)
 	// Close loadBalancer.
+	callStart = traceStart()
 	hr = hcnCloseLoadBalancer(loadBalancerHandle)
-	if err := checkForErrors("hcnCloseLoadBalancer", hr, nil); err != nil {
+	if err := traceEnd("hcnCloseLoadBalancer", callStart, checkForErrors("hcnCloseLoadBalancer", hr, nil)); err != nil {
 		return nil, err
 	}
 	// Convert output to HostComputeLoadBalancer
@@ -99,20 +150,29 @@ func getLoadBalancer(loadBalancerGUID guid.GUID, query string) (*HostComputeLoad
 	return &outputLoadBalancer, nil
 }
 
-func enumerateLoadBalancers(query string) ([]HostComputeLoadBalancer, error) {
+func enumerateLoadBalancerIds(query string) ([]guid.GUID, error) {
+	defer acquireSyscallSlot()()
 	// Enumerate all LoadBalancer Guids
 	var (
 		resultBuffer       *uint16
 		loadBalancerBuffer *uint16
 	)
+	callStart := traceStart()
 	hr := hcnEnumerateLoadBalancers(query, &loadBalancerBuffer, &resultBuffer)
-	if err := checkForErrors("hcnEnumerateLoadBalancers", hr, resultBuffer); err != nil {
+	if err := traceEnd("hcnEnumerateLoadBalancers", callStart, checkForErrors("hcnEnumerateLoadBalancers", hr, &resultBuffer)); err != nil {
 		return nil, err
 	}
 
-	loadBalancers := interop.ConvertAndFreeCoTaskMemString(loadBalancerBuffer)
-	var loadBalancerIds []guid.GUID
-	if err := json.Unmarshal([]byte(loadBalancers), &loadBalancerIds); err != nil {
+	loadBalancers, err := decodeAndFree(loadBalancerBuffer)
+	if err != nil {
+		return nil, err
+	}
+	return decodeGUIDList(loadBalancers)
+}
+
+func enumerateLoadBalancers(query string) ([]HostComputeLoadBalancer, error) {
+	loadBalancerIds, err := enumerateLoadBalancerIds(query)
+	if err != nil {
 		return nil, err
 	}
 
@@ -128,6 +188,10 @@ func enumerateLoadBalancers(query string) ([]HostComputeLoadBalancer, error) {
 }
 
 func createLoadBalancer(settings string) (*HostComputeLoadBalancer, error) {
+	defer acquireSyscallSlot()()
+	if err := checkSettings("hcnCreateLoadBalancer", settings); err != nil {
+		return nil, err
+	}
 	// Create new loadBalancer.
 	var (
 		loadBalancerHandle hcnLoadBalancer
@@ -135,8 +199,9 @@ func createLoadBalancer(settings string) (*HostComputeLoadBalancer, error) {
 		propertiesBuffer   *uint16
 	)
 	loadBalancerGUID := guid.GUID{}
+	callStart := traceStart()
 	hr := hcnCreateLoadBalancer(&loadBalancerGUID, settings, &loadBalancerHandle, &resultBuffer)
-	if err := checkForErrors("hcnCreateLoadBalancer", hr, resultBuffer); err != nil {
+	if err := traceEnd("hcnCreateLoadBalancer", callStart, checkForErrors("hcnCreateLoadBalancer", hr, &resultBuffer)); err != nil {
 		return nil, err
 	}
 	// Query loadBalancer.
@@ -145,14 +210,19 @@ func createLoadBalancer(settings string) (*HostComputeLoadBalancer, error) {
 	if err != nil {
 		return nil, err
 	}
+	callStart = traceStart()
 	hr = hcnQueryLoadBalancerProperties(loadBalancerHandle, string(query), &propertiesBuffer, &resultBuffer)
-	if err := checkForErrors("hcnQueryLoadBalancerProperties", hr, resultBuffer); err != nil {
+	if err := traceEnd("hcnQueryLoadBalancerProperties", callStart, checkForErrors("hcnQueryLoadBalancerProperties", hr, &resultBuffer)); err != nil {
+		return nil, err
+	}
+	properties, err := decodeAndFree(propertiesBuffer)
+	if err != nil {
 		return nil, err
 	}
-	properties := interop.ConvertAndFreeCoTaskMemString(propertiesBuffer)
 	// Close loadBalancer.
+	callStart = traceStart()
 	hr = hcnCloseLoadBalancer(loadBalancerHandle)
-	if err := checkForErrors("hcnCloseLoadBalancer", hr, nil); err != nil {
+	if err := traceEnd("hcnCloseLoadBalancer", callStart, checkForErrors("hcnCloseLoadBalancer", hr, nil)); err != nil {
 		return nil, err
 	}
 	// Convert output to HostComputeLoadBalancer
@@ -164,6 +234,10 @@ func createLoadBalancer(settings string) (*HostComputeLoadBalancer, error) {
 }
 
 func updateLoadBalancer(loadbalancerId string, settings string) (*HostComputeLoadBalancer, error) {
+	defer acquireSyscallSlot()()
+	if err := checkSettings("hcnModifyLoadBalancer", settings); err != nil {
+		return nil, err
+	}
 	loadBalancerGuid, err := guid.FromString(loadbalancerId)
 	if err != nil {
 		return nil, errInvalidLoadBalancerID
@@ -174,12 +248,14 @@ func updateLoadBalancer(loadbalancerId string, settings string) (*HostComputeLoa
 		resultBuffer       *uint16
 		propertiesBuffer   *uint16
 	)
+	callStart := traceStart()
 	hr := hcnOpenLoadBalancer(&loadBalancerGuid, &loadBalancerHandle, &resultBuffer)
-	if err := checkForErrors("hcnOpenLoadBalancer", hr, resultBuffer); err != nil {
+	if err := traceEnd("hcnOpenLoadBalancer", callStart, checkForErrors("hcnOpenLoadBalancer", hr, &resultBuffer)); err != nil {
 		return nil, err
 	}
+	callStart = traceStart()
 	hr = hcnModifyLoadBalancer(loadBalancerHandle, settings, &resultBuffer)
-	if err := checkForErrors("hcnModifyLoadBalancer", hr, resultBuffer); err != nil {
+	if err := traceEnd("hcnModifyLoadBalancer", callStart, checkForErrors("hcnModifyLoadBalancer", hr, &resultBuffer)); err != nil {
 		return nil, err
 	}
 	// Query loadBalancer.
@@ -188,14 +264,19 @@ func updateLoadBalancer(loadbalancerId string, settings string) (*HostComputeLoa
 	if err != nil {
 		return nil, err
 	}
+	callStart = traceStart()
 	hr = hcnQueryLoadBalancerProperties(loadBalancerHandle, string(query), &propertiesBuffer, &resultBuffer)
-	if err := checkForErrors("hcnQueryLoadBalancerProperties", hr, resultBuffer); err != nil {
+	if err := traceEnd("hcnQueryLoadBalancerProperties", callStart, checkForErrors("hcnQueryLoadBalancerProperties", hr, &resultBuffer)); err != nil {
+		return nil, err
+	}
+	properties, err := decodeAndFree(propertiesBuffer)
+	if err != nil {
 		return nil, err
 	}
-	properties := interop.ConvertAndFreeCoTaskMemString(propertiesBuffer)
 	// Close loadBalancer.
+	callStart = traceStart()
 	hr = hcnCloseLoadBalancer(loadBalancerHandle)
-	if err := checkForErrors("hcnCloseLoadBalancer", hr, nil); err != nil {
+	if err := traceEnd("hcnCloseLoadBalancer", callStart, checkForErrors("hcnCloseLoadBalancer", hr, nil)); err != nil {
 		return nil, err
 	}
 	// Convert output to HostComputeLoadBalancer
@@ -207,18 +288,93 @@ func updateLoadBalancer(loadbalancerId string, settings string) (*HostComputeLoa
 }
 
 func deleteLoadBalancer(loadBalancerID string) error {
+	defer acquireSyscallSlot()()
 	loadBalancerGUID, err := guid.FromString(loadBalancerID)
 	if err != nil {
 		return errInvalidLoadBalancerID
 	}
 	var resultBuffer *uint16
+	callStart := traceStart()
 	hr := hcnDeleteLoadBalancer(&loadBalancerGUID, &resultBuffer)
-	if err := checkForErrors("hcnDeleteLoadBalancer", hr, resultBuffer); err != nil {
+	if err := traceEnd("hcnDeleteLoadBalancer", callStart, checkForErrors("hcnDeleteLoadBalancer", hr, &resultBuffer)); err != nil {
 		return err
 	}
 	return nil
 }
 
+// LoadBalancersForEndpoint returns the load balancers that reference the
+// endpoint as a backend. HNS does not support filtering load balancers by
+// endpoint, so this enumerates them all and filters locally.
+func LoadBalancersForEndpoint(endpointID string) ([]HostComputeLoadBalancer, error) {
+	endpointGUID, err := guidFromHNSString(endpointID)
+	if err != nil {
+		return nil, errInvalidEndpointID
+	}
+	allLoadBalancers, err := ListLoadBalancers()
+	if err != nil {
+		return nil, err
+	}
+	var loadBalancers []HostComputeLoadBalancer
+	for _, loadBalancer := range allLoadBalancers {
+		for _, endpointReference := range loadBalancer.HostComputeEndpoints {
+			referenceGUID, err := guidFromHNSString(endpointReference)
+			if err == nil && referenceGUID == endpointGUID {
+				loadBalancers = append(loadBalancers, loadBalancer)
+				break
+			}
+		}
+	}
+	return loadBalancers, nil
+}
+
+// LoadBalancersForVIP returns the load balancers whose front-end VIP set
+// includes the given address. HNS does not support filtering load balancers
+// by VIP, so this enumerates them all and queries each with bounded
+// parallelism. Load balancers that could be queried are returned even when
+// others fail; the failures are reported as a combined error alongside the
+// partial result.
+func LoadBalancersForVIP(vip string) ([]*HostComputeLoadBalancer, error) {
+	vipAddr, err := netip.ParseAddr(vip)
+	if err != nil {
+		return nil, fmt.Errorf("invalid VIP %q: %w", vip, err)
+	}
+	logrus.Debugf("hcn::LoadBalancersForVIP vip=%s", vipAddr)
+
+	queryJSON, err := json.Marshal(defaultQuery())
+	if err != nil {
+		return nil, err
+	}
+	loadBalancerIds, err := enumerateLoadBalancerIds(string(queryJSON))
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		mu            sync.Mutex
+		loadBalancers []*HostComputeLoadBalancer
+		errs          []error
+	)
+	runBounded(len(loadBalancerIds), func(i int) {
+		loadBalancer, err := getLoadBalancer(loadBalancerIds[i], string(queryJSON))
+		if err != nil {
+			mu.Lock()
+			errs = append(errs, fmt.Errorf("load balancer %s: %w", loadBalancerIds[i], err))
+			mu.Unlock()
+			return
+		}
+		for _, frontendVIP := range loadBalancer.FrontendVIPs {
+			frontendAddr, err := netip.ParseAddr(frontendVIP)
+			if err == nil && frontendAddr == vipAddr {
+				mu.Lock()
+				loadBalancers = append(loadBalancers, loadBalancer)
+				mu.Unlock()
+				break
+			}
+		}
+	})
+	return loadBalancers, errors.Join(errs...)
+}
+
 // ListLoadBalancers makes a call to list all available loadBalancers.
 func ListLoadBalancers() ([]HostComputeLoadBalancer, error) {
 	hcnQuery := defaultQuery()
@@ -243,8 +399,24 @@ func ListLoadBalancersQuery(query HostComputeQuery) ([]HostComputeLoadBalancer,
 	return loadBalancers, nil
 }
 
+// ListLoadBalancerIDs makes a call to query the list of available loadBalancers
+// and returns their GUIDs. Unlike ListLoadBalancersQuery, it does not open and
+// query each loadBalancer, making it much cheaper for existence checks.
+func ListLoadBalancerIDs(query HostComputeQuery) ([]guid.GUID, error) {
+	queryJSON, err := json.Marshal(query)
+	if err != nil {
+		return nil, err
+	}
+
+	return enumerateLoadBalancerIds(string(queryJSON))
+}
+
 // GetLoadBalancerByID returns the LoadBalancer specified by Id.
 func GetLoadBalancerByID(loadBalancerID string) (*HostComputeLoadBalancer, error) {
+	loadBalancerID, err := normalizeGUIDString("loadBalancerID", loadBalancerID)
+	if err != nil {
+		return nil, err
+	}
 	hcnQuery := defaultQuery()
 	mapA := map[string]string{"ID": loadBalancerID}
 	filter, err := json.Marshal(mapA)
@@ -264,10 +436,48 @@ func GetLoadBalancerByID(loadBalancerID string) (*HostComputeLoadBalancer, error
 }
 
 // Create LoadBalancer.
-func (loadBalancer *HostComputeLoadBalancer) Create() (*HostComputeLoadBalancer, error) {
+func (loadBalancer *HostComputeLoadBalancer) Create(options ...CallOption) (*HostComputeLoadBalancer, error) {
 	logrus.Debugf("hcn::HostComputeLoadBalancer::Create id=%s", loadBalancer.Id)
 
-	jsonString, err := json.Marshal(loadBalancer)
+	for _, mapping := range loadBalancer.PortMappings {
+		for _, portRange := range []*PortRange{mapping.FrontendPortRange, mapping.BackendPortRange} {
+			if portRange == nil {
+				continue
+			}
+			if err := portRange.validate(); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	callOpts := resolveCallOptions(options)
+	settings := *loadBalancer
+	if err := callOpts.applySchemaVersion(&settings.SchemaVersion); err != nil {
+		return nil, err
+	}
+	applyDefaultSchemaVersion(ObjectTypeLoadBalancer, &settings.SchemaVersion)
+
+	if settings.Flags&LoadBalancerFlagsDSR != 0 {
+		features, err := GetCachedSupportedFeatures()
+		if err != nil {
+			return nil, err
+		}
+		if !features.DSR {
+			return nil, fmt.Errorf("direct server return: %w", ErrAPIUnsupported)
+		}
+		if err := validateDSRPortMappings(settings.PortMappings); err != nil {
+			return nil, err
+		}
+	}
+
+	if settings.HealthProbe != nil {
+		sv := settings.SchemaVersion
+		if !sv.AtLeast(2, 2) {
+			return nil, fmt.Errorf("health probes require schema version 2.2, requested %d.%d: %w", sv.Major, sv.Minor, ErrAPIUnsupported)
+		}
+	}
+
+	jsonString, err := json.Marshal(&settings)
 	if err != nil {
 		return nil, err
 	}
@@ -297,6 +507,93 @@ func (loadBalancer *HostComputeLoadBalancer) Update(hnsLoadbalancerID string) (*
 	return loadBalancer, nil
 }
 
+// WithDSR marks the load balancer for Direct Server Return before Create,
+// failing up front with ErrAPIUnsupported when the host cannot do DSR, so
+// the caller never silently gets a non-DSR balancer. Create additionally
+// rejects DSR combined with port mappings it cannot work with.
+func (loadBalancer *HostComputeLoadBalancer) WithDSR() (*HostComputeLoadBalancer, error) {
+	features, err := GetCachedSupportedFeatures()
+	if err != nil {
+		return nil, err
+	}
+	if !features.DSR {
+		return nil, fmt.Errorf("direct server return: %w", ErrAPIUnsupported)
+	}
+	if err := validateDSRPortMappings(loadBalancer.PortMappings); err != nil {
+		return nil, err
+	}
+	loadBalancer.Flags |= LoadBalancerFlagsDSR
+	return loadBalancer, nil
+}
+
+// validateDSRPortMappings rejects port mapping flags that cannot be combined
+// with Direct Server Return: DSR bypasses the return NAT that internal load
+// balancing and host-routed VIPs rely on.
+func validateDSRPortMappings(portMappings []LoadBalancerPortMapping) error {
+	for i, mapping := range portMappings {
+		if mapping.Flags&LoadBalancerPortMappingFlagsILB != 0 {
+			return fmt.Errorf("port mapping %d: internal load balancing cannot be combined with direct server return", i)
+		}
+		if mapping.Flags&LoadBalancerPortMappingFlagsLocalRoutedVIP != 0 {
+			return fmt.Errorf("port mapping %d: local routed VIP cannot be combined with direct server return", i)
+		}
+	}
+	return nil
+}
+
+// SetFlag enables or disables a single load balancer flag, leaving the rest
+// of the settings as HNS currently holds them. The current properties are
+// re-queried first, so the modify carries fresh state with only the one flag
+// flipped; when the flag is already in the desired state no modify is issued.
+// The receiver is updated to the load balancer's post-modify state.
+func (loadBalancer *HostComputeLoadBalancer) SetFlag(flag LoadBalancerFlags, enabled bool) error {
+	logrus.Debugf("hcn::HostComputeLoadBalancer::SetFlag id=%s flag=%d enabled=%t", loadBalancer.Id, flag, enabled)
+
+	features, err := GetCachedSupportedFeatures()
+	if err != nil {
+		return err
+	}
+	switch flag {
+	case LoadBalancerFlagsDSR:
+		if !features.DSR {
+			return fmt.Errorf("direct server return: %w", ErrAPIUnsupported)
+		}
+	case LoadBalancerFlagsIPv6:
+		if !features.IPv6DualStack {
+			return fmt.Errorf("IPv6 load balancing: %w", ErrAPIUnsupported)
+		}
+	default:
+		return fmt.Errorf("unknown load balancer flag 0x%x", uint32(flag))
+	}
+
+	current, err := GetLoadBalancerByID(loadBalancer.Id)
+	if err != nil {
+		return err
+	}
+	desired := current.Flags
+	if enabled {
+		desired |= flag
+	} else {
+		desired &^= flag
+	}
+	if desired == current.Flags {
+		*loadBalancer = *current
+		return nil
+	}
+	current.Flags = desired
+
+	settings, err := json.Marshal(current)
+	if err != nil {
+		return err
+	}
+	updated, err := updateLoadBalancer(current.Id, string(settings))
+	if err != nil {
+		return err
+	}
+	*loadBalancer = *updated
+	return nil
+}
+
 // Delete LoadBalancer.
 func (loadBalancer *HostComputeLoadBalancer) Delete() error {
 	logrus.Debugf("hcn::HostComputeLoadBalancer::Delete id=%s", loadBalancer.Id)
@@ -343,6 +640,60 @@ func (loadBalancer *HostComputeLoadBalancer) RemoveEndpoint(endpoint *HostComput
 	return loadBalancer.Create()
 }
 
+// LoadBalancerGroup tracks the set of load balancers created to cover one
+// port range, so they can be managed and deleted together.
+type LoadBalancerGroup struct {
+	LoadBalancers []*HostComputeLoadBalancer
+}
+
+// Delete deletes every load balancer in the group, continuing past failures
+// and joining any errors.
+func (group *LoadBalancerGroup) Delete() error {
+	var errs []error
+	for _, loadBalancer := range group.LoadBalancers {
+		if err := loadBalancer.Delete(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// AddLoadBalancerPortRange expands a frontend/backend port range into
+// discrete load balancers, for builds whose HNS does not accept port ranges
+// in a single mapping. The ranges must be the same size; port i of the
+// frontend range maps to port i of the backend range. On a mid-way failure
+// the already-created load balancers are deleted, so no partial group is left
+// behind.
+func AddLoadBalancerPortRange(endpoints []HostComputeEndpoint, flags LoadBalancerFlags, portMappingFlags LoadBalancerPortMappingFlags, sourceVIP string, frontendVIPs []string, protocol uint16, frontend PortRange, backend PortRange) (*LoadBalancerGroup, error) {
+	logrus.Debugf("hcn::HostComputeLoadBalancer::AddLoadBalancerPortRange sourceVIP=%s frontendVIPs=%v protocol=%v frontend=%d-%d backend=%d-%d", sourceVIP, frontendVIPs, protocol, frontend.Start, frontend.End, backend.Start, backend.End)
+
+	if err := frontend.validate(); err != nil {
+		return nil, err
+	}
+	if err := backend.validate(); err != nil {
+		return nil, err
+	}
+	if frontend.End-frontend.Start != backend.End-backend.Start {
+		return nil, fmt.Errorf("frontend range %d-%d and backend range %d-%d differ in size", frontend.Start, frontend.End, backend.Start, backend.End)
+	}
+
+	group := &LoadBalancerGroup{}
+	for offset := uint16(0); ; offset++ {
+		loadBalancer, err := AddLoadBalancer(endpoints, flags, portMappingFlags, sourceVIP, frontendVIPs, protocol, backend.Start+offset, frontend.Start+offset)
+		if err != nil {
+			if deleteErr := group.Delete(); deleteErr != nil {
+				return nil, fmt.Errorf("cleanup of partially created group failed: %w: %s", err, deleteErr)
+			}
+			return nil, err
+		}
+		group.LoadBalancers = append(group.LoadBalancers, loadBalancer)
+		if frontend.Start+offset == frontend.End {
+			break
+		}
+	}
+	return group, nil
+}
+
 // AddLoadBalancer for the specified endpoints
 func AddLoadBalancer(endpoints []HostComputeEndpoint, flags LoadBalancerFlags, portMappingFlags LoadBalancerPortMappingFlags, sourceVIP string, frontendVIPs []string, protocol uint16, internalPort uint16, externalPort uint16) (*HostComputeLoadBalancer, error) {
 	logrus.Debugf("hcn::HostComputeLoadBalancer::AddLoadBalancer endpointId=%v, LoadBalancerFlags=%v, LoadBalancerPortMappingFlags=%v, sourceVIP=%s, frontendVIPs=%v, protocol=%v, internalPort=%v, externalPort=%v", endpoints, flags, portMappingFlags, sourceVIP, frontendVIPs, protocol, internalPort, externalPort)
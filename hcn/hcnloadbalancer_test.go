@@ -320,3 +320,41 @@ func TestAddILBLoadBalancer(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+func TestLoadBalancerHealthProbeJSONRoundTrip(t *testing.T) {
+	loadBalancer := HostComputeLoadBalancer{
+		SchemaVersion: SchemaVersion{Major: 2, Minor: 2},
+		HealthProbe: &LoadBalancerHealthProbe{
+			Protocol:           6,
+			IntervalInSeconds:  5,
+			TimeoutInSeconds:   2,
+			UnhealthyThreshold: 3,
+		},
+	}
+	jsonString, err := json.Marshal(&loadBalancer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `{"SchemaVersion":{"Major":2,"Minor":2},"HealthProbe":{"Protocol":6,"IntervalInSeconds":5,"TimeoutInSeconds":2,"UnhealthyThreshold":3}}`
+	if string(jsonString) != expected {
+		t.Fatalf("expected %s, got %s", expected, jsonString)
+	}
+
+	var decoded HostComputeLoadBalancer
+	if err := json.Unmarshal(jsonString, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.HealthProbe == nil || *decoded.HealthProbe != *loadBalancer.HealthProbe {
+		t.Fatalf("health probe did not round-trip: %+v", decoded.HealthProbe)
+	}
+}
+
+func TestLoadBalancerHealthProbeRequiresSchema22(t *testing.T) {
+	loadBalancer := HostComputeLoadBalancer{
+		SchemaVersion: SchemaVersion{Major: 2, Minor: 0},
+		HealthProbe:   &LoadBalancerHealthProbe{Protocol: 6},
+	}
+	if _, err := loadBalancer.Create(); err == nil {
+		t.Fatal("expected health probe on schema 2.0 to be rejected")
+	}
+}
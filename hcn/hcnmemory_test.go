@@ -0,0 +1,80 @@
+//go:build windows && integration
+// +build windows,integration
+
+package hcn
+
+import (
+	"testing"
+	"unsafe"
+
+	"github.com/Microsoft/go-winio/pkg/guid"
+	"golang.org/x/sys/windows"
+)
+
+var (
+	modpsapi                 = windows.NewLazySystemDLL("psapi.dll")
+	procGetProcessMemoryInfo = modpsapi.NewProc("GetProcessMemoryInfo")
+)
+
+type processMemoryCounters struct {
+	CB                         uint32
+	PageFaultCount             uint32
+	PeakWorkingSetSize         uintptr
+	WorkingSetSize             uintptr
+	QuotaPeakPagedPoolUsage    uintptr
+	QuotaPagedPoolUsage        uintptr
+	QuotaPeakNonPagedPoolUsage uintptr
+	QuotaNonPagedPoolUsage     uintptr
+	PagefileUsage              uintptr
+	PeakPagefileUsage          uintptr
+}
+
+// privateBytes returns the process's current pagefile-backed (private)
+// allocation, which includes the CoTaskMem heap HNS result buffers live on.
+func privateBytes(t *testing.T) uintptr {
+	t.Helper()
+	var counters processMemoryCounters
+	counters.CB = uint32(unsafe.Sizeof(counters))
+	r1, _, err := procGetProcessMemoryInfo.Call(
+		uintptr(windows.CurrentProcess()),
+		uintptr(unsafe.Pointer(&counters)),
+		uintptr(counters.CB),
+	)
+	if r1 == 0 {
+		t.Fatalf("GetProcessMemoryInfo failed: %v", err)
+	}
+	return counters.PagefileUsage
+}
+
+// TestFailingCallsDoNotLeakResultBuffers drives the error path of the
+// wrappers in a loop and asserts private memory stays bounded, so result
+// buffers HNS allocates on failing calls are being freed.
+func TestFailingCallsDoNotLeakResultBuffers(t *testing.T) {
+	missing := guid.GUID{Data1: 0xdeadbeef}
+
+	// Warm up so lazily loaded DLLs and caches do not count against the bound.
+	for i := 0; i < 100; i++ {
+		_, _ = getNetwork(missing, "")
+	}
+	before := privateBytes(t)
+
+	const iterations = 10000
+	for i := 0; i < iterations; i++ {
+		if _, err := getNetwork(missing, ""); err == nil {
+			t.Fatal("expected open of missing network to fail")
+		}
+		if _, err := getEndpoint(missing, ""); err == nil {
+			t.Fatal("expected open of missing endpoint to fail")
+		}
+		if err := deleteNetwork(missing.String()); err == nil {
+			t.Fatal("expected delete of missing network to fail")
+		}
+	}
+
+	after := privateBytes(t)
+	const allowedGrowth = 8 << 20 // generous headroom for allocator noise
+	if after > before && after-before > allowedGrowth {
+		t.Fatalf("private bytes grew by %d over %d failing calls, want <= %d",
+			after-before, iterations, allowedGrowth)
+	}
+}
@@ -3,14 +3,15 @@
 package hcn
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"os"
 	"syscall"
 
 	"github.com/Microsoft/go-winio/pkg/guid"
 	icni "github.com/Microsoft/hcsshim/internal/cni"
-	"github.com/Microsoft/hcsshim/internal/interop"
 	"github.com/Microsoft/hcsshim/internal/regstate"
 	"github.com/Microsoft/hcsshim/internal/runhcs"
 	"github.com/sirupsen/logrus"
@@ -73,26 +74,41 @@ type ModifyNamespaceSettingRequest struct {
 	Settings     json.RawMessage       `json:",omitempty"`
 }
 
-func getNamespace(namespaceGUID guid.GUID, query string) (*HostComputeNamespace, error) {
+func getNamespaceProperties(namespaceGUID guid.GUID, query string) (string, error) {
+	defer acquireSyscallSlot()()
 	// Open namespace.
 	var (
 		namespaceHandle  hcnNamespace
 		resultBuffer     *uint16
 		propertiesBuffer *uint16
 	)
+	callStart := traceStart()
 	hr := hcnOpenNamespace(&namespaceGUID, &namespaceHandle, &resultBuffer)
-	if err := checkForErrors("hcnOpenNamespace", hr, resultBuffer); err != nil {
-		return nil, err
+	if err := traceEnd("hcnOpenNamespace", callStart, checkForErrors("hcnOpenNamespace", hr, &resultBuffer)); err != nil {
+		return "", err
 	}
 	// Query namespace.
+	callStart = traceStart()
 	hr = hcnQueryNamespaceProperties(namespaceHandle, query, &propertiesBuffer, &resultBuffer)
-	if err := checkForErrors("hcnQueryNamespaceProperties", hr, resultBuffer); err != nil {
-		return nil, err
+	if err := traceEnd("hcnQueryNamespaceProperties", callStart, checkForErrors("hcnQueryNamespaceProperties", hr, &resultBuffer)); err != nil {
+		return "", err
+	}
+	properties, err := decodeAndFree(propertiesBuffer)
+	if err != nil {
+		return "", err
 	}
-	properties := interop.ConvertAndFreeCoTaskMemString(propertiesBuffer)
 	// Close namespace.
+	callStart = traceStart()
 	hr = hcnCloseNamespace(namespaceHandle)
-	if err := checkForErrors("hcnCloseNamespace", hr, nil); err != nil {
+	if err := traceEnd("hcnCloseNamespace", callStart, checkForErrors("hcnCloseNamespace", hr, nil)); err != nil {
+		return "", err
+	}
+	return properties, nil
+}
+
+func getNamespace(namespaceGUID guid.GUID, query string) (*HostComputeNamespace, error) {
+	properties, err := getNamespaceProperties(namespaceGUID, query)
+	if err != nil {
 		return nil, err
 	}
 	// Convert output to HostComputeNamespace
@@ -103,20 +119,160 @@ func getNamespace(namespaceGUID guid.GUID, query string) (*HostComputeNamespace,
 	return &outputNamespace, nil
 }
 
-func enumerateNamespaces(query string) ([]HostComputeNamespace, error) {
+// NamespaceState is the lifecycle state the service reports for a namespace.
+type NamespaceState string
+
+var (
+	// NamespaceStateCreated means the namespace exists but no compartment is
+	// attached yet.
+	NamespaceStateCreated NamespaceState = "Created"
+	// NamespaceStateAttached means a compartment is attached.
+	NamespaceStateAttached NamespaceState = "Attached"
+	// NamespaceStateDetached means the compartment has been detached.
+	NamespaceStateDetached NamespaceState = "Detached"
+)
+
+// NamespaceInfo is the typed view of a namespace's queried properties.
+type NamespaceInfo struct {
+	Type          NamespaceType
+	State         NamespaceState
+	CompartmentID uint32
+	Endpoints     []guid.GUID
+	// Extra preserves properties this package does not model, keyed by their
+	// JSON field name, for forward compatibility with newer schemas.
+	Extra map[string]json.RawMessage
+}
+
+// Info queries the namespace's properties and returns them in typed form:
+// type, state, compartment ID, and the GUIDs of attached endpoints. Fields
+// of the properties document this package does not model are preserved
+// verbatim in Extra.
+func (namespace *HostComputeNamespace) Info() (*NamespaceInfo, error) {
+	logrus.Debugf("hcn::HostComputeNamespace::Info id=%s", namespace.Id)
+
+	namespaceGUID, err := guid.FromString(namespace.Id)
+	if err != nil {
+		return nil, errInvalidNamespaceID
+	}
+	queryJSON, err := json.Marshal(defaultQuery())
+	if err != nil {
+		return nil, err
+	}
+	properties, err := getNamespaceProperties(namespaceGUID, string(queryJSON))
+	if err != nil {
+		return nil, err
+	}
+
+	var typed struct {
+		HostComputeNamespace
+		State NamespaceState `json:",omitempty"`
+	}
+	if err := json.Unmarshal([]byte(properties), &typed); err != nil {
+		return nil, err
+	}
+	info := &NamespaceInfo{
+		Type:          typed.Type,
+		State:         typed.State,
+		CompartmentID: typed.NamespaceId,
+	}
+	for _, resource := range typed.Resources {
+		if resource.Type != NamespaceResourceTypeEndpoint {
+			continue
+		}
+		var endpoint NamespaceResourceEndpoint
+		if err := json.Unmarshal(resource.Data, &endpoint); err != nil {
+			return nil, err
+		}
+		endpointGUID, err := guid.FromString(endpoint.Id)
+		if err != nil {
+			return nil, errInvalidEndpointID
+		}
+		info.Endpoints = append(info.Endpoints, endpointGUID)
+	}
+
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(properties), &raw); err != nil {
+		return nil, err
+	}
+	for _, known := range []string{"ID", "NamespaceId", "Type", "Resources", "SchemaVersion", "State"} {
+		delete(raw, known)
+	}
+	if len(raw) > 0 {
+		info.Extra = raw
+	}
+	return info, nil
+}
+
+// Containers queries the namespace's properties and returns the GUIDs of the
+// container compartments attached to it, bounded by ctx. A namespace with no
+// attached containers yields an empty slice, not an error — reconciliation
+// loops use that to tell an orphaned namespace from a query failure.
+func (namespace *HostComputeNamespace) Containers(ctx context.Context) ([]guid.GUID, error) {
+	logrus.Debugf("hcn::HostComputeNamespace::Containers id=%s", namespace.Id)
+
+	namespaceGUID, err := guid.FromString(namespace.Id)
+	if err != nil {
+		return nil, errInvalidNamespaceID
+	}
+	queryJSON, err := json.Marshal(defaultQuery())
+	if err != nil {
+		return nil, err
+	}
+	properties, err := awaitContext(ctx, func() (string, error) {
+		return getNamespaceProperties(namespaceGUID, string(queryJSON))
+	}, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var typed struct {
+		NamespaceId uint32              `json:",omitempty"`
+		Resources   []NamespaceResource `json:",omitempty"`
+	}
+	if err := json.Unmarshal([]byte(properties), &typed); err != nil {
+		return nil, err
+	}
+	containers := make([]guid.GUID, 0, len(typed.Resources))
+	for _, resource := range typed.Resources {
+		if resource.Type != NamespaceResourceTypeContainer {
+			continue
+		}
+		var container NamespaceResourceContainer
+		if err := json.Unmarshal(resource.Data, &container); err != nil {
+			return nil, err
+		}
+		containerGUID, err := guidFromHNSString(container.Id)
+		if err != nil {
+			return nil, fmt.Errorf("namespace %s has malformed container id %q: %w", namespace.Id, container.Id, err)
+		}
+		containers = append(containers, containerGUID)
+	}
+	return containers, nil
+}
+
+func enumerateNamespaceIds(query string) ([]guid.GUID, error) {
+	defer acquireSyscallSlot()()
 	// Enumerate all Namespace Guids
 	var (
 		resultBuffer    *uint16
 		namespaceBuffer *uint16
 	)
+	callStart := traceStart()
 	hr := hcnEnumerateNamespaces(query, &namespaceBuffer, &resultBuffer)
-	if err := checkForErrors("hcnEnumerateNamespaces", hr, resultBuffer); err != nil {
+	if err := traceEnd("hcnEnumerateNamespaces", callStart, checkForErrors("hcnEnumerateNamespaces", hr, &resultBuffer)); err != nil {
+		return nil, err
+	}
+
+	namespaces, err := decodeAndFree(namespaceBuffer)
+	if err != nil {
 		return nil, err
 	}
+	return decodeGUIDList(namespaces)
+}
 
-	namespaces := interop.ConvertAndFreeCoTaskMemString(namespaceBuffer)
-	var namespaceIds []guid.GUID
-	if err := json.Unmarshal([]byte(namespaces), &namespaceIds); err != nil {
+func enumerateNamespaces(query string) ([]HostComputeNamespace, error) {
+	namespaceIds, err := enumerateNamespaceIds(query)
+	if err != nil {
 		return nil, err
 	}
 
@@ -132,6 +288,10 @@ func enumerateNamespaces(query string) ([]HostComputeNamespace, error) {
 }
 
 func createNamespace(settings string) (*HostComputeNamespace, error) {
+	defer acquireSyscallSlot()()
+	if err := checkSettings("hcnCreateNamespace", settings); err != nil {
+		return nil, err
+	}
 	// Create new namespace.
 	var (
 		namespaceHandle  hcnNamespace
@@ -139,8 +299,9 @@ func createNamespace(settings string) (*HostComputeNamespace, error) {
 		propertiesBuffer *uint16
 	)
 	namespaceGUID := guid.GUID{}
+	callStart := traceStart()
 	hr := hcnCreateNamespace(&namespaceGUID, settings, &namespaceHandle, &resultBuffer)
-	if err := checkForErrors("hcnCreateNamespace", hr, resultBuffer); err != nil {
+	if err := traceEnd("hcnCreateNamespace", callStart, checkForErrors("hcnCreateNamespace", hr, &resultBuffer)); err != nil {
 		return nil, err
 	}
 	// Query namespace.
@@ -149,14 +310,19 @@ func createNamespace(settings string) (*HostComputeNamespace, error) {
 	if err != nil {
 		return nil, err
 	}
+	callStart = traceStart()
 	hr = hcnQueryNamespaceProperties(namespaceHandle, string(query), &propertiesBuffer, &resultBuffer)
-	if err := checkForErrors("hcnQueryNamespaceProperties", hr, resultBuffer); err != nil {
+	if err := traceEnd("hcnQueryNamespaceProperties", callStart, checkForErrors("hcnQueryNamespaceProperties", hr, &resultBuffer)); err != nil {
+		return nil, err
+	}
+	properties, err := decodeAndFree(propertiesBuffer)
+	if err != nil {
 		return nil, err
 	}
-	properties := interop.ConvertAndFreeCoTaskMemString(propertiesBuffer)
 	// Close namespace.
+	callStart = traceStart()
 	hr = hcnCloseNamespace(namespaceHandle)
-	if err := checkForErrors("hcnCloseNamespace", hr, nil); err != nil {
+	if err := traceEnd("hcnCloseNamespace", callStart, checkForErrors("hcnCloseNamespace", hr, nil)); err != nil {
 		return nil, err
 	}
 	// Convert output to HostComputeNamespace
@@ -168,6 +334,10 @@ func createNamespace(settings string) (*HostComputeNamespace, error) {
 }
 
 func modifyNamespace(namespaceID string, settings string) (*HostComputeNamespace, error) {
+	defer acquireSyscallSlot()()
+	if err := checkSettings("hcnModifyNamespace", settings); err != nil {
+		return nil, err
+	}
 	namespaceGUID, err := guid.FromString(namespaceID)
 	if err != nil {
 		return nil, errInvalidNamespaceID
@@ -178,13 +348,15 @@ func modifyNamespace(namespaceID string, settings string) (*HostComputeNamespace
 		resultBuffer     *uint16
 		propertiesBuffer *uint16
 	)
+	callStart := traceStart()
 	hr := hcnOpenNamespace(&namespaceGUID, &namespaceHandle, &resultBuffer)
-	if err := checkForErrors("hcnOpenNamespace", hr, resultBuffer); err != nil {
+	if err := traceEnd("hcnOpenNamespace", callStart, checkForErrors("hcnOpenNamespace", hr, &resultBuffer)); err != nil {
 		return nil, err
 	}
 	// Modify namespace.
+	callStart = traceStart()
 	hr = hcnModifyNamespace(namespaceHandle, settings, &resultBuffer)
-	if err := checkForErrors("hcnModifyNamespace", hr, resultBuffer); err != nil {
+	if err := traceEnd("hcnModifyNamespace", callStart, checkForErrors("hcnModifyNamespace", hr, &resultBuffer)); err != nil {
 		return nil, err
 	}
 	// Query namespace.
@@ -193,14 +365,19 @@ func modifyNamespace(namespaceID string, settings string) (*HostComputeNamespace
 	if err != nil {
 		return nil, err
 	}
+	callStart = traceStart()
 	hr = hcnQueryNamespaceProperties(namespaceHandle, string(query), &propertiesBuffer, &resultBuffer)
-	if err := checkForErrors("hcnQueryNamespaceProperties", hr, resultBuffer); err != nil {
+	if err := traceEnd("hcnQueryNamespaceProperties", callStart, checkForErrors("hcnQueryNamespaceProperties", hr, &resultBuffer)); err != nil {
+		return nil, err
+	}
+	properties, err := decodeAndFree(propertiesBuffer)
+	if err != nil {
 		return nil, err
 	}
-	properties := interop.ConvertAndFreeCoTaskMemString(propertiesBuffer)
 	// Close namespace.
+	callStart = traceStart()
 	hr = hcnCloseNamespace(namespaceHandle)
-	if err := checkForErrors("hcnCloseNamespace", hr, nil); err != nil {
+	if err := traceEnd("hcnCloseNamespace", callStart, checkForErrors("hcnCloseNamespace", hr, nil)); err != nil {
 		return nil, err
 	}
 	// Convert output to Namespace
@@ -212,13 +389,15 @@ func modifyNamespace(namespaceID string, settings string) (*HostComputeNamespace
 }
 
 func deleteNamespace(namespaceID string) error {
+	defer acquireSyscallSlot()()
 	namespaceGUID, err := guid.FromString(namespaceID)
 	if err != nil {
 		return errInvalidNamespaceID
 	}
 	var resultBuffer *uint16
+	callStart := traceStart()
 	hr := hcnDeleteNamespace(&namespaceGUID, &resultBuffer)
-	if err := checkForErrors("hcnDeleteNamespace", hr, resultBuffer); err != nil {
+	if err := traceEnd("hcnDeleteNamespace", callStart, checkForErrors("hcnDeleteNamespace", hr, &resultBuffer)); err != nil {
 		return err
 	}
 	return nil
@@ -248,8 +427,24 @@ func ListNamespacesQuery(query HostComputeQuery) ([]HostComputeNamespace, error)
 	return namespaces, nil
 }
 
+// ListNamespaceIDs makes a call to query the list of available namespaces and
+// returns their GUIDs. Unlike ListNamespacesQuery, it does not open and query
+// each namespace, making it much cheaper for existence checks.
+func ListNamespaceIDs(query HostComputeQuery) ([]guid.GUID, error) {
+	queryJSON, err := json.Marshal(query)
+	if err != nil {
+		return nil, err
+	}
+
+	return enumerateNamespaceIds(string(queryJSON))
+}
+
 // GetNamespaceByID returns the Namespace specified by Id.
 func GetNamespaceByID(namespaceID string) (*HostComputeNamespace, error) {
+	namespaceID, err := normalizeGUIDString("namespaceID", namespaceID)
+	if err != nil {
+		return nil, err
+	}
 	hcnQuery := defaultQuery()
 	mapA := map[string]string{"ID": namespaceID}
 	filter, err := json.Marshal(mapA)
@@ -316,10 +511,17 @@ func NewNamespace(nsType NamespaceType) *HostComputeNamespace {
 }
 
 // Create Namespace.
-func (namespace *HostComputeNamespace) Create() (*HostComputeNamespace, error) {
+func (namespace *HostComputeNamespace) Create(options ...CallOption) (*HostComputeNamespace, error) {
 	logrus.Debugf("hcn::HostComputeNamespace::Create id=%s", namespace.Id)
 
-	jsonString, err := json.Marshal(namespace)
+	callOpts := resolveCallOptions(options)
+	settings := *namespace
+	if err := callOpts.applySchemaVersion(&settings.SchemaVersion); err != nil {
+		return nil, err
+	}
+	applyDefaultSchemaVersion(ObjectTypeNamespace, &settings.SchemaVersion)
+
+	jsonString, err := json.Marshal(&settings)
 	if err != nil {
 		return nil, err
 	}
@@ -332,6 +534,29 @@ func (namespace *HostComputeNamespace) Create() (*HostComputeNamespace, error) {
 	return namespace, nil
 }
 
+// CreateOrOpenNamespace creates the namespace under the given ID, or, when
+// one with that ID already exists, returns the existing namespace instead of
+// failing. The boolean reports whether this call created it. The existing
+// namespace is returned as-is, not reconciled against desired.
+func CreateOrOpenNamespace(id guid.GUID, desired *HostComputeNamespace) (*HostComputeNamespace, bool, error) {
+	logrus.Debugf("hcn::HostComputeNamespace::CreateOrOpenNamespace id=%s", id)
+
+	settings := *desired
+	settings.Id = id.String()
+	created, err := settings.Create()
+	if err == nil {
+		return created, true, nil
+	}
+	if !IsAlreadyExistsError(err) {
+		return nil, false, err
+	}
+	existing, err := GetNamespaceByID(id.String())
+	if err != nil {
+		return nil, false, err
+	}
+	return existing, false, nil
+}
+
 // Delete Namespace.
 func (namespace *HostComputeNamespace) Delete() error {
 	logrus.Debugf("hcn::HostComputeNamespace::Delete id=%s", namespace.Id)
@@ -448,3 +673,65 @@ func RemoveNamespaceEndpoint(namespaceID string, endpointID string) error {
 
 	return ModifyNamespaceSettings(namespaceID, requestMessage)
 }
+
+// AddEndpoint hot-attaches an existing endpoint to the namespace. The
+// endpoint is verified first, so attaching a GUID that does not name an
+// endpoint reports ErrEndpointNotFound instead of whatever HNS makes of a
+// dangling reference in the modify document.
+func (namespace *HostComputeNamespace) AddEndpoint(id guid.GUID) error {
+	logrus.Debugf("hcn::HostComputeNamespace::AddEndpoint id=%s endpoint=%s", namespace.Id, id)
+
+	if _, err := GetEndpointByID(id.String()); err != nil {
+		if IsNotFoundError(err) {
+			return fmt.Errorf("endpoint %s: %w", id, ErrEndpointNotFound)
+		}
+		return err
+	}
+	return AddNamespaceEndpoint(namespace.Id, id.String())
+}
+
+// RemoveEndpoint hot-detaches an endpoint from the namespace, with the same
+// up-front existence check as AddEndpoint.
+func (namespace *HostComputeNamespace) RemoveEndpoint(id guid.GUID) error {
+	logrus.Debugf("hcn::HostComputeNamespace::RemoveEndpoint id=%s endpoint=%s", namespace.Id, id)
+
+	if _, err := GetEndpointByID(id.String()); err != nil {
+		if IsNotFoundError(err) {
+			return fmt.Errorf("endpoint %s: %w", id, ErrEndpointNotFound)
+		}
+		return err
+	}
+	return RemoveNamespaceEndpoint(namespace.Id, id.String())
+}
+
+// MoveEndpointToNamespace moves an endpoint between namespaces: it detaches
+// the endpoint from fromNS, attaches it to toNS (the two modify calls are the
+// fewest HNS offers for a move), and verifies by query that the endpoint ended
+// up in toNS. If the attach fails after the detach already succeeded, the
+// endpoint is reattached to fromNS so it is not left orphaned, and the
+// returned error reports whether that recovery worked.
+func MoveEndpointToNamespace(endpointID, fromNS, toNS guid.GUID) error {
+	logrus.Debugf("hcn::HostComputeNamespace::MoveEndpointToNamespace endpoint=%s from=%s to=%s", endpointID, fromNS, toNS)
+
+	if err := RemoveNamespaceEndpoint(fromNS.String(), endpointID.String()); err != nil {
+		return fmt.Errorf("detach endpoint %s from namespace %s: %w", endpointID, fromNS, err)
+	}
+	if err := AddNamespaceEndpoint(toNS.String(), endpointID.String()); err != nil {
+		attachErr := fmt.Errorf("attach endpoint %s to namespace %s: %w", endpointID, toNS, err)
+		if recoveryErr := AddNamespaceEndpoint(fromNS.String(), endpointID.String()); recoveryErr != nil {
+			return fmt.Errorf("%w; reattaching to namespace %s also failed, endpoint is detached: %w", attachErr, fromNS, recoveryErr)
+		}
+		return fmt.Errorf("%w; endpoint was reattached to namespace %s", attachErr, fromNS)
+	}
+
+	// Trust the query, not the modify calls, for the final state.
+	endpoint, err := GetEndpointByID(endpointID.String())
+	if err != nil {
+		return fmt.Errorf("verify move of endpoint %s: %w", endpointID, err)
+	}
+	attached, err := guid.FromString(endpoint.HostComputeNamespace)
+	if err != nil || attached != toNS {
+		return fmt.Errorf("endpoint %s reports namespace %q after move to namespace %s", endpointID, endpoint.HostComputeNamespace, toNS)
+	}
+	return nil
+}
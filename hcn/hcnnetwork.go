@@ -5,9 +5,14 @@ package hcn
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
+	"math"
+	"net/netip"
+	"reflect"
+	"slices"
+	"strings"
 
 	"github.com/Microsoft/go-winio/pkg/guid"
-	"github.com/Microsoft/hcsshim/internal/interop"
 	"github.com/sirupsen/logrus"
 )
 
@@ -74,6 +79,10 @@ const (
 	EnableNonPersistent NetworkFlags = 8
 	DisableHostPort     NetworkFlags = 1024
 	EnableIov           NetworkFlags = 8192
+	// EnableConfidential marks the network for confidential (hardware
+	// isolated) workloads. Requires Windows Server 2025 (HNS 15.4); see
+	// WithConfidential.
+	EnableConfidential NetworkFlags = 16384
 )
 
 // HostComputeNetwork represents a network
@@ -115,25 +124,32 @@ type PolicyNetworkRequest struct {
 }
 
 func getNetwork(networkGUID guid.GUID, query string) (*HostComputeNetwork, error) {
+	defer acquireSyscallSlot()()
 	// Open network.
 	var (
 		networkHandle    hcnNetwork
 		resultBuffer     *uint16
 		propertiesBuffer *uint16
 	)
+	callStart := traceStart()
 	hr := hcnOpenNetwork(&networkGUID, &networkHandle, &resultBuffer)
-	if err := checkForErrors("hcnOpenNetwork", hr, resultBuffer); err != nil {
+	if err := traceEnd("hcnOpenNetwork", callStart, checkForErrors("hcnOpenNetwork", hr, &resultBuffer)); err != nil {
 		return nil, err
 	}
 	// Query network.
+	callStart = traceStart()
 	hr = hcnQueryNetworkProperties(networkHandle, query, &propertiesBuffer, &resultBuffer)
-	if err := checkForErrors("hcnQueryNetworkProperties", hr, resultBuffer); err != nil {
+	if err := traceEnd("hcnQueryNetworkProperties", callStart, checkForErrors("hcnQueryNetworkProperties", hr, &resultBuffer)); err != nil {
+		return nil, err
+	}
+	properties, err := decodeAndFree(propertiesBuffer)
+	if err != nil {
 		return nil, err
 	}
-	properties := interop.ConvertAndFreeCoTaskMemString(propertiesBuffer)
 	// Close network.
+	callStart = traceStart()
 	hr = hcnCloseNetwork(networkHandle)
-	if err := checkForErrors("hcnCloseNetwork", hr, nil); err != nil {
+	if err := traceEnd("hcnCloseNetwork", callStart, checkForErrors("hcnCloseNetwork", hr, nil)); err != nil {
 		return nil, err
 	}
 	// Convert output to HostComputeNetwork
@@ -150,20 +166,29 @@ func getNetwork(networkGUID guid.GUID, query string) (*HostComputeNetwork, error
 	return &outputNetwork, nil
 }
 
-func enumerateNetworks(query string) ([]HostComputeNetwork, error) {
+func enumerateNetworkIds(query string) ([]guid.GUID, error) {
+	defer acquireSyscallSlot()()
 	// Enumerate all Network Guids
 	var (
 		resultBuffer  *uint16
 		networkBuffer *uint16
 	)
+	callStart := traceStart()
 	hr := hcnEnumerateNetworks(query, &networkBuffer, &resultBuffer)
-	if err := checkForErrors("hcnEnumerateNetworks", hr, resultBuffer); err != nil {
+	if err := traceEnd("hcnEnumerateNetworks", callStart, checkForErrors("hcnEnumerateNetworks", hr, &resultBuffer)); err != nil {
 		return nil, err
 	}
 
-	networks := interop.ConvertAndFreeCoTaskMemString(networkBuffer)
-	var networkIds []guid.GUID
-	if err := json.Unmarshal([]byte(networks), &networkIds); err != nil {
+	networks, err := decodeAndFree(networkBuffer)
+	if err != nil {
+		return nil, err
+	}
+	return decodeGUIDList(networks)
+}
+
+func enumerateNetworks(query string) ([]HostComputeNetwork, error) {
+	networkIds, err := enumerateNetworkIds(query)
+	if err != nil {
 		return nil, err
 	}
 
@@ -179,6 +204,10 @@ func enumerateNetworks(query string) ([]HostComputeNetwork, error) {
 }
 
 func createNetwork(settings string) (*HostComputeNetwork, error) {
+	defer acquireSyscallSlot()()
+	if err := checkSettings("hcnCreateNetwork", settings); err != nil {
+		return nil, err
+	}
 	// Create new network.
 	var (
 		networkHandle    hcnNetwork
@@ -186,8 +215,9 @@ func createNetwork(settings string) (*HostComputeNetwork, error) {
 		propertiesBuffer *uint16
 	)
 	networkGUID := guid.GUID{}
+	callStart := traceStart()
 	hr := hcnCreateNetwork(&networkGUID, settings, &networkHandle, &resultBuffer)
-	if err := checkForErrors("hcnCreateNetwork", hr, resultBuffer); err != nil {
+	if err := traceEnd("hcnCreateNetwork", callStart, checkForErrors("hcnCreateNetwork", hr, &resultBuffer)); err != nil {
 		return nil, err
 	}
 	// Query network.
@@ -196,14 +226,19 @@ func createNetwork(settings string) (*HostComputeNetwork, error) {
 	if err != nil {
 		return nil, err
 	}
+	callStart = traceStart()
 	hr = hcnQueryNetworkProperties(networkHandle, string(query), &propertiesBuffer, &resultBuffer)
-	if err := checkForErrors("hcnQueryNetworkProperties", hr, resultBuffer); err != nil {
+	if err := traceEnd("hcnQueryNetworkProperties", callStart, checkForErrors("hcnQueryNetworkProperties", hr, &resultBuffer)); err != nil {
+		return nil, err
+	}
+	properties, err := decodeAndFree(propertiesBuffer)
+	if err != nil {
 		return nil, err
 	}
-	properties := interop.ConvertAndFreeCoTaskMemString(propertiesBuffer)
 	// Close network.
+	callStart = traceStart()
 	hr = hcnCloseNetwork(networkHandle)
-	if err := checkForErrors("hcnCloseNetwork", hr, nil); err != nil {
+	if err := traceEnd("hcnCloseNetwork", callStart, checkForErrors("hcnCloseNetwork", hr, nil)); err != nil {
 		return nil, err
 	}
 	// Convert output to HostComputeNetwork
@@ -221,6 +256,10 @@ func createNetwork(settings string) (*HostComputeNetwork, error) {
 }
 
 func modifyNetwork(networkID string, settings string) (*HostComputeNetwork, error) {
+	defer acquireSyscallSlot()()
+	if err := checkSettings("hcnModifyNetwork", settings); err != nil {
+		return nil, err
+	}
 	networkGUID, err := guid.FromString(networkID)
 	if err != nil {
 		return nil, errInvalidNetworkID
@@ -231,13 +270,15 @@ func modifyNetwork(networkID string, settings string) (*HostComputeNetwork, erro
 		resultBuffer     *uint16
 		propertiesBuffer *uint16
 	)
+	callStart := traceStart()
 	hr := hcnOpenNetwork(&networkGUID, &networkHandle, &resultBuffer)
-	if err := checkForErrors("hcnOpenNetwork", hr, resultBuffer); err != nil {
+	if err := traceEnd("hcnOpenNetwork", callStart, checkForErrors("hcnOpenNetwork", hr, &resultBuffer)); err != nil {
 		return nil, err
 	}
 	// Modify Network
+	callStart = traceStart()
 	hr = hcnModifyNetwork(networkHandle, settings, &resultBuffer)
-	if err := checkForErrors("hcnModifyNetwork", hr, resultBuffer); err != nil {
+	if err := traceEnd("hcnModifyNetwork", callStart, checkForErrors("hcnModifyNetwork", hr, &resultBuffer)); err != nil {
 		return nil, err
 	}
 	// Query network.
@@ -246,14 +287,19 @@ func modifyNetwork(networkID string, settings string) (*HostComputeNetwork, erro
 	if err != nil {
 		return nil, err
 	}
+	callStart = traceStart()
 	hr = hcnQueryNetworkProperties(networkHandle, string(query), &propertiesBuffer, &resultBuffer)
-	if err := checkForErrors("hcnQueryNetworkProperties", hr, resultBuffer); err != nil {
+	if err := traceEnd("hcnQueryNetworkProperties", callStart, checkForErrors("hcnQueryNetworkProperties", hr, &resultBuffer)); err != nil {
+		return nil, err
+	}
+	properties, err := decodeAndFree(propertiesBuffer)
+	if err != nil {
 		return nil, err
 	}
-	properties := interop.ConvertAndFreeCoTaskMemString(propertiesBuffer)
 	// Close network.
+	callStart = traceStart()
 	hr = hcnCloseNetwork(networkHandle)
-	if err := checkForErrors("hcnCloseNetwork", hr, nil); err != nil {
+	if err := traceEnd("hcnCloseNetwork", callStart, checkForErrors("hcnCloseNetwork", hr, nil)); err != nil {
 		return nil, err
 	}
 	// Convert output to HostComputeNetwork
@@ -271,13 +317,15 @@ func modifyNetwork(networkID string, settings string) (*HostComputeNetwork, erro
 }
 
 func deleteNetwork(networkID string) error {
+	defer acquireSyscallSlot()()
 	networkGUID, err := guid.FromString(networkID)
 	if err != nil {
 		return errInvalidNetworkID
 	}
 	var resultBuffer *uint16
+	callStart := traceStart()
 	hr := hcnDeleteNetwork(&networkGUID, &resultBuffer)
-	if err := checkForErrors("hcnDeleteNetwork", hr, resultBuffer); err != nil {
+	if err := traceEnd("hcnDeleteNetwork", callStart, checkForErrors("hcnDeleteNetwork", hr, &resultBuffer)); err != nil {
 		return err
 	}
 	return nil
@@ -307,8 +355,24 @@ func ListNetworksQuery(query HostComputeQuery) ([]HostComputeNetwork, error) {
 	return networks, nil
 }
 
+// ListNetworkIDs makes a call to query the list of available networks and
+// returns their GUIDs. Unlike ListNetworksQuery, it does not open and query
+// each network, making it much cheaper for existence checks.
+func ListNetworkIDs(query HostComputeQuery) ([]guid.GUID, error) {
+	queryJSON, err := json.Marshal(query)
+	if err != nil {
+		return nil, err
+	}
+
+	return enumerateNetworkIds(string(queryJSON))
+}
+
 // GetNetworkByID returns the network specified by Id.
 func GetNetworkByID(networkID string) (*HostComputeNetwork, error) {
+	networkID, err := normalizeGUIDString("networkID", networkID)
+	if err != nil {
+		return nil, err
+	}
 	hcnQuery := defaultQuery()
 	mapA := map[string]string{"ID": networkID}
 	filter, err := json.Marshal(mapA)
@@ -347,29 +411,356 @@ func GetNetworkByName(networkName string) (*HostComputeNetwork, error) {
 	return &networks[0], err
 }
 
-// Create Network.
-func (network *HostComputeNetwork) Create() (*HostComputeNetwork, error) {
-	logrus.Debugf("hcn::HostComputeNetwork::Create id=%s", network.Id)
+// CreateServiceNetwork creates a network and its baseline load balancer
+// together. If the load balancer cannot be created, the network that was just
+// created is deleted again, so a mid-way failure does not leave a
+// half-configured network behind. On success both objects are returned so
+// their lifecycles can be managed together.
+func CreateServiceNetwork(netSettings *HostComputeNetwork, lbSettings *HostComputeLoadBalancer) (*HostComputeNetwork, *HostComputeLoadBalancer, error) {
+	logrus.Debugf("hcn::CreateServiceNetwork network=%q", netSettings.Name)
+
+	network, err := netSettings.Create()
+	if err != nil {
+		return nil, nil, err
+	}
+	loadBalancer, err := lbSettings.Create()
+	if err != nil {
+		if deleteErr := network.Delete(); deleteErr != nil {
+			return nil, nil, fmt.Errorf("cleanup of network %s failed: %w: %s", network.Id, err, deleteErr)
+		}
+		return nil, nil, err
+	}
+	return network, loadBalancer, nil
+}
+
+// ModifyNetworks applies the same modify request to every network in ids,
+// concurrently with bounded parallelism, and reports which networks
+// succeeded and which failed. It is the network analog of
+// ApplyPoliciesToEndpoints for rolling one settings change across many
+// networks.
+func ModifyNetworks(ids []guid.GUID, req *ModifyNetworkSettingRequest) BatchResult[guid.GUID] {
+	logrus.Debugf("hcn::HostComputeNetwork::ModifyNetworks count=%d", len(ids))
+
+	errs := make([]error, len(ids))
+	runBounded(len(ids), func(i int) {
+		network := &HostComputeNetwork{Id: ids[i].String()}
+		errs[i] = network.ModifyNetworkSettings(req)
+	})
+
+	var result BatchResult[guid.GUID]
+	for i, err := range errs {
+		if err != nil {
+			result.Failed = append(result.Failed, BatchFailure[guid.GUID]{Item: ids[i], Err: err})
+			continue
+		}
+		result.Succeeded = append(result.Succeeded, ids[i])
+	}
+	return result
+}
+
+// SubnetUsage reports how full one of a network's subnets is. Total is the
+// number of usable host addresses in the prefix: the full address count less
+// the IPv4 network and broadcast addresses (prefixes of /31 and shorter).
+// Reserved counts the subnet's gateways, taken from the next hops of its
+// routes, which HNS holds back from assignment. Assigned counts endpoint IP
+// configurations inside the prefix, and Free is Total - Assigned - Reserved.
+// Counts saturate at the maximum uint64 for very large IPv6 prefixes.
+type SubnetUsage struct {
+	Prefix   netip.Prefix
+	Total    uint64
+	Assigned uint64
+	Reserved uint64
+	Free     uint64
+}
+
+// SubnetUtilization computes per-subnet address usage for capacity planning,
+// from the network's IPAM configuration and its enumerated endpoints. See
+// SubnetUsage for how the counts are defined.
+func (network *HostComputeNetwork) SubnetUtilization() ([]SubnetUsage, error) {
+	logrus.Debugf("hcn::HostComputeNetwork::SubnetUtilization id=%s", network.Id)
+
+	endpoints, err := ListEndpointsOfNetwork(network.Id)
+	if err != nil {
+		return nil, err
+	}
+	var assigned []netip.Addr
+	for _, endpoint := range endpoints {
+		for _, ipConfig := range endpoint.IpConfigurations {
+			addr, err := netip.ParseAddr(ipConfig.IpAddress)
+			if err != nil {
+				continue
+			}
+			assigned = append(assigned, addr)
+		}
+	}
+
+	var usages []SubnetUsage
 	for _, ipam := range network.Ipams {
 		for _, subnet := range ipam.Subnets {
-			if subnet.IpAddressPrefix != "" {
-				hasDefault := false
-				for _, route := range subnet.Routes {
-					if route.NextHop == "" {
-						return nil, errors.New("network create error, subnet has address prefix but no gateway specified")
-					}
-					if route.DestinationPrefix == "0.0.0.0/0" || route.DestinationPrefix == "::/0" {
-						hasDefault = true
-					}
+			prefix, err := netip.ParsePrefix(subnet.IpAddressPrefix)
+			if err != nil {
+				return nil, fmt.Errorf("network %s has invalid subnet prefix %q: %w", network.Id, subnet.IpAddressPrefix, err)
+			}
+			usage := SubnetUsage{Prefix: prefix}
+
+			hostBits := prefix.Addr().BitLen() - prefix.Bits()
+			if hostBits >= 64 {
+				usage.Total = math.MaxUint64
+			} else {
+				usage.Total = uint64(1) << hostBits
+				if prefix.Addr().Is4() && hostBits >= 2 {
+					// Network and broadcast addresses are not assignable.
+					usage.Total -= 2
 				}
-				if !hasDefault {
-					return nil, errors.New("network create error, no default gateway")
+			}
+
+			gateways := make(map[netip.Addr]struct{})
+			for _, route := range subnet.Routes {
+				gateway, err := netip.ParseAddr(route.NextHop)
+				if err == nil && prefix.Contains(gateway) {
+					gateways[gateway] = struct{}{}
+				}
+			}
+			usage.Reserved = uint64(len(gateways))
+
+			for _, addr := range assigned {
+				if prefix.Contains(addr) {
+					usage.Assigned++
 				}
 			}
+
+			if used := usage.Assigned + usage.Reserved; used < usage.Total {
+				usage.Free = usage.Total - used
+			}
+			usages = append(usages, usage)
+		}
+	}
+	return usages, nil
+}
+
+// Dependents returns the objects that would be affected by deleting the
+// network: the endpoints attached to it, and the load balancers referencing
+// any of those endpoints. It is a read-only blast-radius check, suitable for
+// a confirmation prompt before Delete.
+func (network *HostComputeNetwork) Dependents() (endpoints []guid.GUID, loadBalancers []guid.GUID, err error) {
+	logrus.Debugf("hcn::HostComputeNetwork::Dependents id=%s", network.Id)
+
+	networkEndpoints, err := ListEndpointsOfNetwork(network.Id)
+	if err != nil {
+		return nil, nil, err
+	}
+	endpointIds := make(map[guid.GUID]struct{}, len(networkEndpoints))
+	for _, endpoint := range networkEndpoints {
+		endpointGUID, err := guidFromHNSString(endpoint.Id)
+		if err != nil {
+			return nil, nil, errInvalidEndpointID
+		}
+		endpoints = append(endpoints, endpointGUID)
+		endpointIds[endpointGUID] = struct{}{}
+	}
+	if len(endpoints) == 0 {
+		return nil, nil, nil
+	}
+
+	allLoadBalancers, err := ListLoadBalancers()
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, loadBalancer := range allLoadBalancers {
+		for _, endpointID := range loadBalancer.HostComputeEndpoints {
+			endpointGUID, err := guidFromHNSString(endpointID)
+			if err != nil {
+				continue
+			}
+			if _, ok := endpointIds[endpointGUID]; !ok {
+				continue
+			}
+			loadBalancerGUID, err := guid.FromString(loadBalancer.Id)
+			if err != nil {
+				return nil, nil, errInvalidLoadBalancerID
+			}
+			loadBalancers = append(loadBalancers, loadBalancerGUID)
+			break
 		}
 	}
+	return endpoints, loadBalancers, nil
+}
 
-	jsonString, err := json.Marshal(network)
+// EffectiveGatewayDNS returns the gateway addresses and DNS configuration a
+// new endpoint on this network would inherit, without creating one. The
+// network is re-queried from the service so the answer reflects current
+// state; the gateways are the next hops of the subnet routes in the
+// network's IPAM configuration, and the DNS settings are the network's own.
+func (network *HostComputeNetwork) EffectiveGatewayDNS() ([]netip.Addr, Dns, error) {
+	logrus.Debugf("hcn::HostComputeNetwork::EffectiveGatewayDNS id=%s", network.Id)
+
+	current, err := GetNetworkByID(network.Id)
+	if err != nil {
+		return nil, Dns{}, err
+	}
+
+	var gateways []netip.Addr
+	for _, ipam := range current.Ipams {
+		for _, subnet := range ipam.Subnets {
+			for _, route := range subnet.Routes {
+				if route.NextHop == "" {
+					continue
+				}
+				gateway, err := netip.ParseAddr(route.NextHop)
+				if err != nil {
+					return nil, Dns{}, fmt.Errorf("parse gateway address %q: %w", route.NextHop, err)
+				}
+				gateways = append(gateways, gateway)
+			}
+		}
+	}
+	return gateways, current.Dns, nil
+}
+
+// WithConfidential sets the EnableConfidential flag for confidential
+// container workloads, for use before Create. Confidential networking
+// requires Windows Server 2025 (HNS 15.4); on older builds
+// ErrAPIUnsupported is returned. It is only valid on Transparent and Overlay
+// networks, where traffic stays on an isolated data path.
+func (network *HostComputeNetwork) WithConfidential() (*HostComputeNetwork, error) {
+	if err := confidentialSupported(); err != nil {
+		return nil, err
+	}
+	if network.Type != Transparent && network.Type != Overlay {
+		return nil, fmt.Errorf("confidential networking is not valid on %s networks", network.Type)
+	}
+	network.Flags |= EnableConfidential
+	return network, nil
+}
+
+// confidentialSupported gates the confidential networking flags on the
+// running HNS version.
+func confidentialSupported() error {
+	globals, err := GetGlobals()
+	if err != nil {
+		return err
+	}
+	if !isFeatureSupported(globals.Version, ConfidentialNetworkingVersion) {
+		return fmt.Errorf("confidential networking requires Windows Server 2025 (HNS 15.4): %w", ErrAPIUnsupported)
+	}
+	return nil
+}
+
+// WithEncapPort attaches a VxlanPort policy configuring the UDP port used for
+// VXLAN encapsulation, for use before Create. Networks without the policy
+// encapsulate on DefaultVxlanPort.
+func (network *HostComputeNetwork) WithEncapPort(port uint16) (*HostComputeNetwork, error) {
+	policy, err := NewVxlanPortNetworkPolicy(port)
+	if err != nil {
+		return nil, err
+	}
+	network.Policies = append(network.Policies, *policy)
+	return network, nil
+}
+
+// EncapPort reports the UDP port the network encapsulates VXLAN traffic on:
+// the port from its VxlanPort policy, or DefaultVxlanPort when none is set.
+func (network *HostComputeNetwork) EncapPort() (uint16, error) {
+	for _, policy := range network.Policies {
+		if policy.Type != VxlanPort {
+			continue
+		}
+		var setting VxlanPortPolicySetting
+		if err := json.Unmarshal(policy.Settings, &setting); err != nil {
+			return 0, err
+		}
+		return setting.Port, nil
+	}
+	return DefaultVxlanPort, nil
+}
+
+// FindDuplicateNames enumerates all networks and returns each friendly name
+// shared by more than one network, with the IDs of the networks carrying it.
+// Duplicate names break name-based lookups such as GetNetworkByName, which
+// silently return one of the conflicting networks; this read-only diagnostic
+// lets operators find and repair the conflict first.
+func FindDuplicateNames() (map[string][]guid.GUID, error) {
+	networks, err := ListNetworks()
+	if err != nil {
+		return nil, err
+	}
+
+	byName := make(map[string][]guid.GUID)
+	for _, network := range networks {
+		networkGUID, err := guid.FromString(network.Id)
+		if err != nil {
+			return nil, errInvalidNetworkID
+		}
+		byName[network.Name] = append(byName[network.Name], networkGUID)
+	}
+
+	duplicates := make(map[string][]guid.GUID)
+	for name, ids := range byName {
+		if len(ids) > 1 {
+			duplicates[name] = ids
+		}
+	}
+	return duplicates, nil
+}
+
+// NetworksWithPolicy returns all networks carrying a policy of the given type.
+// The per-network property queries run concurrently with bounded parallelism;
+// networks that fail to query contribute to the returned error while the
+// matching networks are still returned.
+func NetworksWithPolicy(policyType NetworkPolicyType) ([]*HostComputeNetwork, error) {
+	hcnQuery := defaultQuery()
+	queryJSON, err := json.Marshal(hcnQuery)
+	if err != nil {
+		return nil, err
+	}
+	networkIds, err := enumerateNetworkIds(string(queryJSON))
+	if err != nil {
+		return nil, err
+	}
+
+	networks := make([]*HostComputeNetwork, len(networkIds))
+	errs := make([]error, len(networkIds))
+	runBounded(len(networkIds), func(i int) {
+		network, err := getNetwork(networkIds[i], string(queryJSON))
+		if err != nil {
+			errs[i] = fmt.Errorf("query of network %s failed: %w", networkIds[i], err)
+			return
+		}
+		for _, policy := range network.Policies {
+			if policy.Type == policyType {
+				networks[i] = network
+				break
+			}
+		}
+	})
+
+	var outputNetworks []*HostComputeNetwork
+	for _, network := range networks {
+		if network != nil {
+			outputNetworks = append(outputNetworks, network)
+		}
+	}
+	return outputNetworks, errors.Join(errs...)
+}
+
+// Create Network.
+func (network *HostComputeNetwork) Create(options ...CallOption) (*HostComputeNetwork, error) {
+	logrus.Debugf("hcn::HostComputeNetwork::Create id=%s", network.Id)
+	if err := ValidateNetworkSettings(network); err != nil {
+		return nil, fmt.Errorf("network create error: %w", err)
+	}
+
+	callOpts := resolveCallOptions(options)
+	settings := *network
+	if err := callOpts.applySchemaVersion(&settings.SchemaVersion); err != nil {
+		return nil, err
+	}
+	applyDefaultSchemaVersion(ObjectTypeNetwork, &settings.SchemaVersion)
+	settings.Policies = slices.Clone(settings.Policies)
+	if err := orderPoliciesByPriority(settings.Policies); err != nil {
+		return nil, err
+	}
+	jsonString, err := json.Marshal(&settings)
 	if err != nil {
 		return nil, err
 	}
@@ -382,6 +773,30 @@ func (network *HostComputeNetwork) Create() (*HostComputeNetwork, error) {
 	return network, nil
 }
 
+// CreateOrOpenNetwork creates the network under the given ID, or, when one
+// with that ID already exists, returns the existing network instead of
+// failing. The boolean reports whether this call created it. Re-running a
+// reconcile loop through this function is therefore safe; note the existing
+// network's settings are returned as-is, not reconciled against desired.
+func CreateOrOpenNetwork(id guid.GUID, desired *HostComputeNetwork) (*HostComputeNetwork, bool, error) {
+	logrus.Debugf("hcn::HostComputeNetwork::CreateOrOpenNetwork id=%s", id)
+
+	settings := *desired
+	settings.Id = id.String()
+	created, err := settings.Create()
+	if err == nil {
+		return created, true, nil
+	}
+	if !IsAlreadyExistsError(err) {
+		return nil, false, err
+	}
+	existing, err := GetNetworkByID(id.String())
+	if err != nil {
+		return nil, false, err
+	}
+	return existing, false, nil
+}
+
 // Delete Network.
 func (network *HostComputeNetwork) Delete() error {
 	logrus.Debugf("hcn::HostComputeNetwork::Delete id=%s", network.Id)
@@ -392,6 +807,24 @@ func (network *HostComputeNetwork) Delete() error {
 	return nil
 }
 
+// SafeDelete deletes the network only if no load balancers still reference
+// its endpoints, since deleting underneath them leaves dangling load balancer
+// state. When dependents exist it returns ErrNetworkInUse naming them.
+// Delete remains the forcing variant for callers that accept the dangling
+// state.
+func (network *HostComputeNetwork) SafeDelete() error {
+	logrus.Debugf("hcn::HostComputeNetwork::SafeDelete id=%s", network.Id)
+
+	_, loadBalancers, err := network.Dependents()
+	if err != nil {
+		return err
+	}
+	if len(loadBalancers) > 0 {
+		return fmt.Errorf("network %s has dependent load balancers %v: %w", network.Id, loadBalancers, ErrNetworkInUse)
+	}
+	return network.Delete()
+}
+
 // ModifyNetworkSettings updates the Policy for a network.
 func (network *HostComputeNetwork) ModifyNetworkSettings(request *ModifyNetworkSettingRequest) error {
 	logrus.Debugf("hcn::HostComputeNetwork::ModifyNetworkSettings id=%s", network.Id)
@@ -412,6 +845,10 @@ func (network *HostComputeNetwork) ModifyNetworkSettings(request *ModifyNetworkS
 func (network *HostComputeNetwork) AddPolicy(networkPolicy PolicyNetworkRequest) error {
 	logrus.Debugf("hcn::HostComputeNetwork::AddPolicy id=%s", network.Id)
 
+	networkPolicy.Policies = slices.Clone(networkPolicy.Policies)
+	if err := orderPoliciesByPriority(networkPolicy.Policies); err != nil {
+		return err
+	}
 	settingsJSON, err := json.Marshal(networkPolicy)
 	if err != nil {
 		return err
@@ -442,11 +879,91 @@ func (network *HostComputeNetwork) RemovePolicy(networkPolicy PolicyNetworkReque
 	return network.ModifyNetworkSettings(requestMessage)
 }
 
+// networkHasPolicy reports whether policies contains one matching policy by
+// type and settings. Settings are compared structurally, not byte for byte,
+// since HNS re-serializes documents with its own field order.
+func networkHasPolicy(policies []NetworkPolicy, policy NetworkPolicy) (bool, error) {
+	var want interface{}
+	if len(policy.Settings) > 0 {
+		if err := json.Unmarshal(policy.Settings, &want); err != nil {
+			return false, err
+		}
+	}
+	for _, existing := range policies {
+		if existing.Type != policy.Type {
+			continue
+		}
+		var have interface{}
+		if len(existing.Settings) > 0 {
+			if err := json.Unmarshal(existing.Settings, &have); err != nil {
+				return false, err
+			}
+		}
+		if reflect.DeepEqual(have, want) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// AddSinglePolicy applies one policy to the network as an Add delta, leaving
+// every other policy untouched — unlike rewriting the settings document,
+// which risks clobbering concurrent changes. A policy the network already
+// carries is not re-applied, so reconcile loops can repeat their desired set
+// safely.
+func (network *HostComputeNetwork) AddSinglePolicy(policy NetworkPolicy) error {
+	logrus.Debugf("hcn::HostComputeNetwork::AddSinglePolicy id=%s type=%s", network.Id, policy.Type)
+
+	current, err := GetNetworkByID(network.Id)
+	if err != nil {
+		return err
+	}
+	present, err := networkHasPolicy(current.Policies, policy)
+	if err != nil {
+		return err
+	}
+	if present {
+		return nil
+	}
+	return network.AddPolicy(PolicyNetworkRequest{Policies: []NetworkPolicy{policy}})
+}
+
+// RemoveSinglePolicy removes one policy from the network as a Remove delta,
+// leaving every other policy untouched. Removing a policy the network does
+// not carry is reported as an error naming the policy type, rather than
+// passed to HNS to fail obscurely or silently succeed.
+func (network *HostComputeNetwork) RemoveSinglePolicy(policy NetworkPolicy) error {
+	logrus.Debugf("hcn::HostComputeNetwork::RemoveSinglePolicy id=%s type=%s", network.Id, policy.Type)
+
+	current, err := GetNetworkByID(network.Id)
+	if err != nil {
+		return err
+	}
+	present, err := networkHasPolicy(current.Policies, policy)
+	if err != nil {
+		return err
+	}
+	if !present {
+		return fmt.Errorf("network %s has no %s policy matching the request", network.Id, policy.Type)
+	}
+	return network.RemovePolicy(PolicyNetworkRequest{Policies: []NetworkPolicy{policy}})
+}
+
 // CreateEndpoint creates an endpoint on the Network.
 func (network *HostComputeNetwork) CreateEndpoint(endpoint *HostComputeEndpoint) (*HostComputeEndpoint, error) {
 	isRemote := endpoint.Flags&EndpointFlagsRemoteEndpoint != 0
 	logrus.Debugf("hcn::HostComputeNetwork::CreatEndpoint, networkId=%s remote=%t", network.Id, isRemote)
 
+	// ArpProxy and GatewayMac only make sense where the endpoint shares the
+	// host's L2 segment; reject them up front on other network types.
+	if network.Type != L2Bridge && network.Type != L2Tunnel {
+		for _, policy := range endpoint.Policies {
+			if policy.Type == ArpProxy || policy.Type == GatewayMac {
+				return nil, fmt.Errorf("%s policy is not valid on %s networks", policy.Type, network.Type)
+			}
+		}
+	}
+
 	endpoint.HostComputeNetwork = network.Id
 	endpointSettings, err := json.Marshal(endpoint)
 	if err != nil {
@@ -464,3 +981,113 @@ func (network *HostComputeNetwork) CreateRemoteEndpoint(endpoint *HostComputeEnd
 	endpoint.Flags = EndpointFlagsRemoteEndpoint | endpoint.Flags
 	return network.CreateEndpoint(endpoint)
 }
+
+// subnetWithDefaultRoute builds the subnet document the typed network
+// constructors share: the prefix plus a default route through the first
+// usable address, which HNS requires for any subnet with an address prefix.
+func subnetWithDefaultRoute(prefix netip.Prefix) (Subnet, error) {
+	if prefix != prefix.Masked() {
+		return Subnet{}, fmt.Errorf("subnet %s has host bits set; use %s", prefix, prefix.Masked())
+	}
+	gateway := prefix.Addr().Next()
+	if !prefix.Contains(gateway) {
+		return Subnet{}, fmt.Errorf("subnet %s is too small to hold a gateway address", prefix)
+	}
+	destination := "0.0.0.0/0"
+	if prefix.Addr().Is6() {
+		destination = "::/0"
+	}
+	return Subnet{
+		IpAddressPrefix: prefix.String(),
+		Routes: []Route{
+			{
+				NextHop:           gateway.String(),
+				DestinationPrefix: destination,
+			},
+		},
+	}, nil
+}
+
+// NewNATNetwork returns a NAT network document with every piece HNS requires
+// in place: a static IPAM holding the subnet and its default gateway route.
+// Missing or malformed arguments are reported together in one validation
+// error, before any syscall, so the "network created but unusable" failure
+// mode cannot happen. The caller may adjust the document before Create.
+func NewNATNetwork(name string, subnet netip.Prefix) (*HostComputeNetwork, error) {
+	var missing []string
+	if name == "" {
+		missing = append(missing, "name")
+	}
+	if !subnet.IsValid() {
+		missing = append(missing, "subnet")
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("NAT network is missing required fields: %s", strings.Join(missing, ", "))
+	}
+	subnetDoc, err := subnetWithDefaultRoute(subnet)
+	if err != nil {
+		return nil, err
+	}
+	return &HostComputeNetwork{
+		Name: name,
+		Type: NAT,
+		Ipams: []Ipam{
+			{
+				Type:    "Static",
+				Subnets: []Subnet{subnetDoc},
+			},
+		},
+		SchemaVersion: V2SchemaVersion(),
+	}, nil
+}
+
+// NewOverlayNetwork returns an Overlay network document with the pieces HNS
+// requires for that type: the static IPAM with subnet and default route as
+// for NAT, plus the VSID subnet policy that isolates the overlay — the piece
+// most often forgotten, yielding a network that creates fine but cannot carry
+// traffic. A zero vsid is reported as missing along with any other absent
+// field.
+func NewOverlayNetwork(name string, subnet netip.Prefix, vsid uint32) (*HostComputeNetwork, error) {
+	var missing []string
+	if name == "" {
+		missing = append(missing, "name")
+	}
+	if !subnet.IsValid() {
+		missing = append(missing, "subnet")
+	}
+	if vsid == 0 {
+		missing = append(missing, "vsid")
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("overlay network is missing required fields: %s", strings.Join(missing, ", "))
+	}
+	subnetDoc, err := subnetWithDefaultRoute(subnet)
+	if err != nil {
+		return nil, err
+	}
+
+	vsidJSON, err := json.Marshal(&VsidPolicySetting{IsolationId: vsid})
+	if err != nil {
+		return nil, err
+	}
+	subnetPolicyJSON, err := json.Marshal(&SubnetPolicy{
+		Type:     VSID,
+		Settings: vsidJSON,
+	})
+	if err != nil {
+		return nil, err
+	}
+	subnetDoc.Policies = append(subnetDoc.Policies, subnetPolicyJSON)
+
+	return &HostComputeNetwork{
+		Name: name,
+		Type: Overlay,
+		Ipams: []Ipam{
+			{
+				Type:    "Static",
+				Subnets: []Subnet{subnetDoc},
+			},
+		},
+		SchemaVersion: V2SchemaVersion(),
+	}, nil
+}
@@ -7,6 +7,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"strings"
 	"testing"
 )
 
@@ -178,6 +179,88 @@ func TestAddRemoveNetworACLPolicy(t *testing.T) {
 	testNetworkPolicy(t, networkACLPolicy)
 }
 
+func TestNetworkACLPolicyPriorityOrder(t *testing.T) {
+	aclPolicy := func(priority uint32, localPorts string) NetworkPolicy {
+		in := NetworkACLPolicySetting{
+			Protocols:       "6",
+			Action:          ActionTypeAllow,
+			Direction:       DirectionTypeIn,
+			LocalAddresses:  "192.168.100.0/24",
+			RemoteAddresses: "192.168.100.0/24",
+			LocalPorts:      localPorts,
+			RuleType:        RuleTypeSwitch,
+			Priority:        200,
+		}
+		rawJSON, err := json.Marshal(in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return NetworkPolicy{
+			Type:     NetworkACL,
+			Settings: rawJSON,
+			Priority: priority,
+		}
+	}
+
+	network, err := CreateTestOverlayNetwork()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = network.Delete()
+	}()
+
+	// Declared out of priority order; HNS must receive and apply 10 before 20.
+	err = network.AddPolicy(PolicyNetworkRequest{
+		Policies: []NetworkPolicy{aclPolicy(20, "8080"), aclPolicy(10, "80")},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	//Reload the network object from HNS.
+	network, err = GetNetworkByID(network.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Priority is client-side ordering metadata and never reaches HNS, so the
+	// reloaded policies carry it as zero. The ordering is observable through
+	// the ACL settings themselves: the priority-10 rule ("80") must appear
+	// before the priority-20 rule ("8080").
+	var localPorts []string
+	for _, policy := range network.Policies {
+		if policy.Type != NetworkACL {
+			continue
+		}
+		var setting NetworkACLPolicySetting
+		if err := json.Unmarshal(policy.Settings, &setting); err != nil {
+			t.Fatal(err)
+		}
+		localPorts = append(localPorts, setting.LocalPorts)
+	}
+	portIndex := func(port string) int {
+		for i, p := range localPorts {
+			if p == port {
+				return i
+			}
+		}
+		t.Fatalf("ACL policy with local ports %q not found on network after query", port)
+		return -1
+	}
+	if portIndex("80") > portIndex("8080") {
+		t.Fatalf("priority 10 policy applied after priority 20 policy; ACL local ports in order: %v", localPorts)
+	}
+
+	// Duplicate non-zero priorities must be rejected before reaching HNS.
+	err = network.AddPolicy(PolicyNetworkRequest{
+		Policies: []NetworkPolicy{aclPolicy(30, "443"), aclPolicy(30, "8443")},
+	})
+	if err == nil {
+		t.Fatal("expected duplicate policy priorities to be rejected")
+	}
+}
+
 func TestNetworkFlags(t *testing.T) {
 	network, err := CreateTestOverlayNetwork()
 	if err != nil {
@@ -199,3 +282,60 @@ func TestNetworkFlags(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+func TestAddRemoveSinglePolicy(t *testing.T) {
+	in := NetworkACLPolicySetting{
+		Protocols:       "6",
+		Action:          ActionTypeAllow,
+		Direction:       DirectionTypeIn,
+		LocalAddresses:  "192.168.100.0/24",
+		RemoteAddresses: "192.168.100.0/24",
+		LocalPorts:      "8080",
+		RuleType:        RuleTypeSwitch,
+		Priority:        200,
+	}
+	rawJSON, err := json.Marshal(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	policy := NetworkPolicy{
+		Type:     NetworkACL,
+		Settings: rawJSON,
+	}
+
+	network, err := CreateTestOverlayNetwork()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = network.Delete()
+	}()
+
+	basePolicies := len(network.Policies)
+	if err := network.AddSinglePolicy(policy); err != nil {
+		t.Fatal(err)
+	}
+	// Re-adding the same policy must be a no-op, not a duplicate.
+	if err := network.AddSinglePolicy(policy); err != nil {
+		t.Fatal(err)
+	}
+	network, err = GetNetworkByID(network.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := len(network.Policies); got != basePolicies+1 {
+		t.Fatalf("expected %d policies after double add, got %d", basePolicies+1, got)
+	}
+
+	if err := network.RemoveSinglePolicy(policy); err != nil {
+		t.Fatal(err)
+	}
+	// The policy is gone now; removing it again must fail clearly.
+	err = network.RemoveSinglePolicy(policy)
+	if err == nil {
+		t.Fatal("expected removing a nonexistent policy to fail")
+	}
+	if !strings.Contains(err.Error(), string(NetworkACL)) {
+		t.Fatalf("error should name the policy type, got: %s", err)
+	}
+}
@@ -4,6 +4,14 @@ package hcn
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"sort"
+	"strings"
+
+	"github.com/Microsoft/go-winio/pkg/guid"
 )
 
 // EndpointPolicyType are the potential Policies that apply to Endpoints.
@@ -26,6 +34,11 @@ const (
 	NetworkProviderAddress     EndpointPolicyType = "ProviderAddress"
 	NetworkInterfaceConstraint EndpointPolicyType = "InterfaceConstraint"
 	TierAcl                    EndpointPolicyType = "TierAcl"
+	RoutingPriority            EndpointPolicyType = "RoutingPriority"
+	ArpProxy                   EndpointPolicyType = "ArpProxy"
+	GatewayMac                 EndpointPolicyType = "GatewayMac"
+	PacketCapture              EndpointPolicyType = "PacketCapture"
+	Mtu                        EndpointPolicyType = "Mtu"
 )
 
 // EndpointPolicy is a collection of Policy settings for an Endpoint.
@@ -53,12 +66,45 @@ const (
 	NetworkL4Proxy      NetworkPolicyType = "L4Proxy"
 	LayerConstraint     NetworkPolicyType = "LayerConstraint"
 	NetworkACL          NetworkPolicyType = "NetworkACL"
+	NetworkMtu          NetworkPolicyType = "Mtu"
 )
 
 // NetworkPolicy is a collection of Policy settings for a Network.
 type NetworkPolicy struct {
 	Type     NetworkPolicyType `json:""`
 	Settings json.RawMessage   `json:",omitempty"`
+	// Priority orders this policy relative to the network's other policies
+	// where the order is significant, such as network ACLs. Lower values
+	// apply first. Zero leaves the policy unprioritized; unprioritized
+	// policies keep their declared order after all prioritized ones. The
+	// field is client-side ordering metadata, not part of the HNS schema:
+	// it is never marshaled into the document sent to the service.
+	Priority uint32 `json:"-"`
+}
+
+// orderPoliciesByPriority sorts policies so HNS receives them in the order
+// they should apply: ascending non-zero priority first, then unprioritized
+// policies in their declared order. Non-zero priorities must be unique, since
+// HNS resolves ties by an order we would not control.
+func orderPoliciesByPriority(policies []NetworkPolicy) error {
+	seen := make(map[uint32]NetworkPolicyType)
+	for _, policy := range policies {
+		if policy.Priority == 0 {
+			continue
+		}
+		if other, ok := seen[policy.Priority]; ok {
+			return fmt.Errorf("network policies %s and %s share priority %d", other, policy.Type, policy.Priority)
+		}
+		seen[policy.Priority] = policy.Type
+	}
+	sort.SliceStable(policies, func(i, j int) bool {
+		pi, pj := policies[i].Priority, policies[j].Priority
+		if pi == 0 || pj == 0 {
+			return pj == 0 && pi != 0
+		}
+		return pi < pj
+	})
+	return nil
 }
 
 // SubnetPolicyType are the potential Policies that apply to Subnets.
@@ -156,6 +202,7 @@ type SDNRoutePolicySetting struct {
 	DestinationPrefix string `json:",omitempty"`
 	NextHop           string `json:",omitempty"`
 	NeedEncap         bool   `json:",omitempty"`
+	Metric            uint32 `json:",omitempty"`
 }
 
 // NetworkACLPolicySetting creates ACL rules on a network
@@ -214,6 +261,96 @@ type IovPolicySetting struct {
 	InterruptModeration uint32 `json:",omitempty"`
 }
 
+// RoutingPriorityEndpointPolicySetting assigns a preference among the networks
+// a multi-homed endpoint is attached to. Lower values are preferred; backup
+// paths carry higher values.
+type RoutingPriorityEndpointPolicySetting struct {
+	Priority uint32 `json:",omitempty"`
+}
+
+// NewRoutingPriorityEndpointPolicy creates a RoutingPriority EndpointPolicy.
+// The priority must be non-zero, as HNS treats zero as unset.
+func NewRoutingPriorityEndpointPolicy(priority uint32) (*EndpointPolicy, error) {
+	if priority == 0 {
+		return nil, errors.New("routing priority must be non-zero")
+	}
+	settings, err := json.Marshal(RoutingPriorityEndpointPolicySetting{Priority: priority})
+	if err != nil {
+		return nil, err
+	}
+	return &EndpointPolicy{
+		Type:     RoutingPriority,
+		Settings: settings,
+	}, nil
+}
+
+// ArpProxyEndpointPolicySetting makes the endpoint answer ARP requests for
+// its gateway address itself, instead of relying on the gateway being
+// reachable on the L2 segment.
+type ArpProxyEndpointPolicySetting struct {
+	Enabled bool `json:",omitempty"`
+}
+
+// GatewayMacEndpointPolicySetting overrides the MAC address the endpoint
+// resolves for its gateway.
+type GatewayMacEndpointPolicySetting struct {
+	MacAddress string `json:",omitempty"`
+}
+
+// NewGatewayMacEndpointPolicy creates a GatewayMac EndpointPolicy. The MAC
+// address is validated with net.ParseMAC.
+func NewGatewayMacEndpointPolicy(mac string) (*EndpointPolicy, error) {
+	if _, err := net.ParseMAC(mac); err != nil {
+		return nil, fmt.Errorf("invalid gateway MAC address %q: %w", mac, err)
+	}
+	settings, err := json.Marshal(GatewayMacEndpointPolicySetting{MacAddress: mac})
+	if err != nil {
+		return nil, err
+	}
+	return &EndpointPolicy{
+		Type:     GatewayMac,
+		Settings: settings,
+	}, nil
+}
+
+// CaptureOptions tune per-endpoint packet capture.
+type CaptureOptions struct {
+	// MaxPacketSize truncates each captured packet to this many bytes;
+	// zero captures whole packets.
+	MaxPacketSize uint32 `json:",omitempty"`
+	// CaptureInbound and CaptureOutbound select the directions captured.
+	// When both are false, both directions are captured.
+	CaptureInbound  bool `json:",omitempty"`
+	CaptureOutbound bool `json:",omitempty"`
+}
+
+// PacketCaptureEndpointPolicySetting toggles packet capture on an endpoint.
+type PacketCaptureEndpointPolicySetting struct {
+	Enabled bool `json:",omitempty"`
+	CaptureOptions
+}
+
+// DefaultVxlanPort is the UDP port HNS encapsulates VXLAN traffic on when a
+// network carries no VxlanPort policy.
+const DefaultVxlanPort = 4789
+
+// NewVxlanPortNetworkPolicy creates a VxlanPort NetworkPolicy configuring the
+// UDP port used for VXLAN encapsulation. Ports in the well-known range are
+// rejected, as they cannot plausibly carry tunnel traffic.
+func NewVxlanPortNetworkPolicy(port uint16) (*NetworkPolicy, error) {
+	if port < 1024 {
+		return nil, fmt.Errorf("encap port %d is in the well-known port range", port)
+	}
+	settings, err := json.Marshal(VxlanPortPolicySetting{Port: port})
+	if err != nil {
+		return nil, err
+	}
+	return &NetworkPolicy{
+		Type:     VxlanPort,
+		Settings: settings,
+	}, nil
+}
+
 /// Endpoint and Network Policy objects
 
 // ProviderAddressEndpointPolicySetting sets the PA for an endpoint.
@@ -299,11 +436,104 @@ type SetPolicySetting struct {
 	Values string
 }
 
+// NewSetPolicy returns a SetPolicy network policy defining a named set of
+// values (for example remote IPs) that ACLs can reference as a group instead
+// of repeating the values in every rule. HNS identifies sets by GUID; one is
+// generated here, and NewSetReferenceAclPolicy resolves names back to it.
+func NewSetPolicy(name string, setType SetPolicyType, values []string) (*NetworkPolicy, error) {
+	if name == "" {
+		return nil, errors.New("set policy requires a name")
+	}
+	switch setType {
+	case SetPolicyTypeIpSet, SetPolicyTypeNestedIpSet:
+	default:
+		return nil, fmt.Errorf("unknown set policy type %q", setType)
+	}
+	if len(values) == 0 {
+		return nil, fmt.Errorf("set policy %q requires at least one value", name)
+	}
+	id, err := guid.NewV4()
+	if err != nil {
+		return nil, err
+	}
+	rawJSON, err := json.Marshal(SetPolicySetting{
+		Id:     id.String(),
+		Name:   name,
+		Type:   setType,
+		Values: strings.Join(values, ","),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &NetworkPolicy{
+		Type:     SetPolicy,
+		Settings: rawJSON,
+	}, nil
+}
+
+// SetPolicies parses and returns the SetPolicy definitions present on the
+// network, so queried networks report the sets their ACLs can reference.
+func (network *HostComputeNetwork) SetPolicies() ([]SetPolicySetting, error) {
+	var settings []SetPolicySetting
+	for _, policy := range network.Policies {
+		if policy.Type != SetPolicy {
+			continue
+		}
+		var setting SetPolicySetting
+		if err := json.Unmarshal(policy.Settings, &setting); err != nil {
+			return nil, fmt.Errorf("network %s has invalid SetPolicy settings: %w", network.Id, err)
+		}
+		settings = append(settings, setting)
+	}
+	return settings, nil
+}
+
+// NewSetReferenceAclPolicy builds an ACL endpoint policy whose remote
+// addresses reference sets defined on the network by name. Every name must
+// match a SetPolicy on the network; the ACL is emitted with the sets' GUIDs,
+// which is what HNS matches on.
+func NewSetReferenceAclPolicy(network *HostComputeNetwork, acl AclPolicySetting, remoteSetNames []string) (*EndpointPolicy, error) {
+	if len(remoteSetNames) == 0 {
+		return nil, errors.New("at least one set name is required")
+	}
+	sets, err := network.SetPolicies()
+	if err != nil {
+		return nil, err
+	}
+	idsByName := make(map[string]string, len(sets))
+	for _, set := range sets {
+		idsByName[set.Name] = set.Id
+	}
+	ids := make([]string, 0, len(remoteSetNames))
+	for _, name := range remoteSetNames {
+		id, ok := idsByName[name]
+		if !ok {
+			return nil, fmt.Errorf("set %q is not defined on network %s", name, network.Id)
+		}
+		ids = append(ids, id)
+	}
+	acl.RemoteAddresses = strings.Join(ids, ",")
+	rawJSON, err := json.Marshal(acl)
+	if err != nil {
+		return nil, err
+	}
+	return &EndpointPolicy{
+		Type:     ACL,
+		Settings: rawJSON,
+	}, nil
+}
+
 // VxlanPortPolicySetting allows configuring the VXLAN TCP port
 type VxlanPortPolicySetting struct {
 	Port uint16
 }
 
+// MtuPolicySetting overrides the MTU on an Endpoint, or sets the default MTU
+// for a Network's endpoints.
+type MtuPolicySetting struct {
+	Mtu uint32 `json:",omitempty"`
+}
+
 // ProtocolType associated with L4ProxyPolicy
 type ProtocolType uint32
 
@@ -345,3 +575,69 @@ type TierAclPolicySetting struct {
 	Order        uint16        `json:""`
 	TierAclRules []TierAclRule `json:",omitempty"`
 }
+
+// newOutboundNATPolicyForSchema builds the OutBoundNAT policy document for
+// one specific schema version; NewOutboundNATPolicy negotiates the version
+// with the service. Schema 1.x named the exception prefixes ExceptionList,
+// 2.x renamed the field to Exceptions; the port range fields are unchanged.
+func newOutboundNATPolicyForSchema(sv SchemaVersion, exceptions []netip.Prefix, portStart, portEnd uint16) (*EndpointPolicy, error) {
+	prefixes := make([]string, 0, len(exceptions))
+	for _, prefix := range exceptions {
+		if !prefix.IsValid() {
+			return nil, fmt.Errorf("invalid outbound NAT exception prefix %s", prefix)
+		}
+		if prefix != prefix.Masked() {
+			return nil, fmt.Errorf("outbound NAT exception prefix %s has host bits set; use %s", prefix, prefix.Masked())
+		}
+		prefixes = append(prefixes, prefix.String())
+	}
+	switch {
+	case portStart == 0 && portEnd == 0:
+		// No port range constraint.
+	case portStart == 0 || portEnd == 0:
+		return nil, fmt.Errorf("outbound NAT port range %d-%d is half-open; set both bounds or neither", portStart, portEnd)
+	case portStart > portEnd:
+		return nil, fmt.Errorf("outbound NAT port range start %d exceeds end %d", portStart, portEnd)
+	}
+
+	var settings []byte
+	var err error
+	if sv.AtLeast(2, 0) {
+		settings, err = json.Marshal(struct {
+			Exceptions     []string `json:",omitempty"`
+			PortRangeStart uint16   `json:",omitempty"`
+			PortRangeEnd   uint16   `json:",omitempty"`
+		}{prefixes, portStart, portEnd})
+	} else {
+		settings, err = json.Marshal(struct {
+			ExceptionList  []string `json:",omitempty"`
+			PortRangeStart uint16   `json:",omitempty"`
+			PortRangeEnd   uint16   `json:",omitempty"`
+		}{prefixes, portStart, portEnd})
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &EndpointPolicy{
+		Type:     OutBoundNAT,
+		Settings: settings,
+	}, nil
+}
+
+// NewOutboundNATPolicy creates an OutBoundNAT EndpointPolicy excepting the
+// given prefixes from NAT and, when portStart and portEnd are both non-zero,
+// constraining NAT to that source port range. The field names in the emitted
+// document follow the newest schema version the running service supports.
+// Prefixes with host bits set and inverted or half-open port ranges are
+// rejected before anything reaches HNS.
+func NewOutboundNATPolicy(exceptions []netip.Prefix, portStart, portEnd uint16) (*EndpointPolicy, error) {
+	supported, err := SupportedSchemaVersions()
+	if err != nil {
+		return nil, err
+	}
+	if len(supported) == 0 {
+		return nil, ErrAPIUnsupported
+	}
+	newest := supported[len(supported)-1]
+	return newOutboundNATPolicyForSchema(newest, exceptions, portStart, portEnd)
+}
@@ -0,0 +1,58 @@
+//go:build windows && integration
+// +build windows,integration
+
+package hcn
+
+import (
+	"net/netip"
+	"testing"
+)
+
+func TestNewOutboundNATPolicyGoldenV2(t *testing.T) {
+	policy, err := newOutboundNATPolicyForSchema(
+		SchemaVersion{Major: 2, Minor: 0},
+		[]netip.Prefix{netip.MustParsePrefix("10.0.0.0/8"), netip.MustParsePrefix("192.168.0.0/16")},
+		1024, 2048,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if policy.Type != OutBoundNAT {
+		t.Errorf("policy type %q, want %q", policy.Type, OutBoundNAT)
+	}
+	want := `{"Exceptions":["10.0.0.0/8","192.168.0.0/16"],"PortRangeStart":1024,"PortRangeEnd":2048}`
+	if got := string(policy.Settings); got != want {
+		t.Errorf("settings document mismatch\ngot:  %s\nwant: %s", got, want)
+	}
+}
+
+func TestNewOutboundNATPolicyGoldenV1(t *testing.T) {
+	policy, err := newOutboundNATPolicyForSchema(
+		SchemaVersion{Major: 1, Minor: 0},
+		[]netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")},
+		0, 0,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"ExceptionList":["10.0.0.0/8"]}`
+	if got := string(policy.Settings); got != want {
+		t.Errorf("settings document mismatch\ngot:  %s\nwant: %s", got, want)
+	}
+}
+
+func TestNewOutboundNATPolicyValidation(t *testing.T) {
+	sv := SchemaVersion{Major: 2, Minor: 0}
+	if _, err := newOutboundNATPolicyForSchema(sv, []netip.Prefix{netip.MustParsePrefix("10.0.0.1/8")}, 0, 0); err == nil {
+		t.Error("expected prefix with host bits set to be rejected")
+	}
+	if _, err := newOutboundNATPolicyForSchema(sv, []netip.Prefix{{}}, 0, 0); err == nil {
+		t.Error("expected invalid prefix to be rejected")
+	}
+	if _, err := newOutboundNATPolicyForSchema(sv, nil, 2048, 1024); err == nil {
+		t.Error("expected inverted port range to be rejected")
+	}
+	if _, err := newOutboundNATPolicyForSchema(sv, nil, 1024, 0); err == nil {
+		t.Error("expected half-open port range to be rejected")
+	}
+}
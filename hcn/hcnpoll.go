@@ -0,0 +1,63 @@
+//go:build windows
+
+package hcn
+
+import (
+	"context"
+	"math/rand/v2"
+	"time"
+)
+
+// PollOptions tune the wait-for-state helpers in this package. The delay
+// between polls starts at Interval and grows exponentially up to MaxInterval.
+type PollOptions struct {
+	// Interval is the initial delay between polls.
+	Interval time.Duration
+	// MaxInterval caps the exponentially growing delay between polls.
+	MaxInterval time.Duration
+}
+
+// DefaultPollOptions returns the polling configuration used when callers do
+// not supply their own.
+func DefaultPollOptions() PollOptions {
+	return PollOptions{
+		Interval:    100 * time.Millisecond,
+		MaxInterval: 2 * time.Second,
+	}
+}
+
+// pollUntil repeatedly invokes fn until it reports done, returns an error, or
+// ctx expires. The delay between polls grows exponentially from opts.Interval
+// up to opts.MaxInterval, with jitter so concurrent waiters do not poll in
+// lockstep.
+func pollUntil(ctx context.Context, opts PollOptions, fn func() (done bool, err error)) error {
+	defaults := DefaultPollOptions()
+	if opts.Interval <= 0 {
+		opts.Interval = defaults.Interval
+	}
+	if opts.MaxInterval < opts.Interval {
+		opts.MaxInterval = opts.Interval
+	}
+
+	interval := opts.Interval
+	for {
+		done, err := fn()
+		if err != nil {
+			return err
+		}
+		if done {
+			return nil
+		}
+		// Jitter the delay into [interval/2, interval].
+		delay := interval/2 + time.Duration(rand.Int64N(int64(interval/2)+1))
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+		interval *= 2
+		if interval > opts.MaxInterval {
+			interval = opts.MaxInterval
+		}
+	}
+}
@@ -0,0 +1,114 @@
+//go:build windows
+
+package hcn
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Query incrementally builds a HostComputeQuery, so callers compose filters
+// with typed methods instead of hand-writing the filter JSON — a typo there
+// is not an error to HCN, just a silently empty result. The zero value is
+// the default query (schema 2.0, standard properties, no filter). Methods
+// return a copy, so a partially built Query can be reused as a base.
+type Query struct {
+	schemaVersion *SchemaVersion
+	flags         HostComputeQueryFlags
+	filter        map[string]interface{}
+}
+
+// NewQuery returns the default query to build on.
+func NewQuery() Query {
+	return Query{}
+}
+
+// WithSchemaVersion sets the schema version the query is issued with.
+func (q Query) WithSchemaVersion(major, minor uint32) Query {
+	q.schemaVersion = &SchemaVersion{Major: int(major), Minor: int(minor)}
+	return q
+}
+
+// WithFlags sets the query flags, e.g. HostComputeQueryFlagsDetailed to
+// retrieve all properties.
+func (q Query) WithFlags(flags HostComputeQueryFlags) Query {
+	q.flags = flags
+	return q
+}
+
+// WithFilter adds one key/value pair to the query's filter document. HCN
+// matches filter keys against the enumerated objects' JSON properties, e.g.
+// WithFilter("Name", "my-network") or
+// WithFilter("VirtualNetwork", networkID).
+func (q Query) WithFilter(key string, value interface{}) Query {
+	filter := make(map[string]interface{}, len(q.filter)+1)
+	for k, v := range q.filter {
+		filter[k] = v
+	}
+	filter[key] = value
+	q.filter = filter
+	return q
+}
+
+// Build marshals the accumulated state into the HostComputeQuery document
+// HCN expects, suitable for any of the List*Query functions.
+func (q Query) Build() (HostComputeQuery, error) {
+	query := defaultQuery()
+	if q.schemaVersion != nil {
+		query.SchemaVersion = *q.schemaVersion
+	}
+	query.Flags = q.flags
+	if len(q.filter) > 0 {
+		filterJSON, err := json.Marshal(q.filter)
+		if err != nil {
+			return HostComputeQuery{}, fmt.Errorf("marshal query filter: %w", err)
+		}
+		query.Filter = string(filterJSON)
+	}
+	return query, nil
+}
+
+// Endpoints runs the query against the endpoint enumeration.
+func (q Query) Endpoints() ([]HostComputeEndpoint, error) {
+	query, err := q.Build()
+	if err != nil {
+		return nil, err
+	}
+	return ListEndpointsQuery(query)
+}
+
+// Networks runs the query against the network enumeration.
+func (q Query) Networks() ([]HostComputeNetwork, error) {
+	query, err := q.Build()
+	if err != nil {
+		return nil, err
+	}
+	return ListNetworksQuery(query)
+}
+
+// Namespaces runs the query against the namespace enumeration.
+func (q Query) Namespaces() ([]HostComputeNamespace, error) {
+	query, err := q.Build()
+	if err != nil {
+		return nil, err
+	}
+	return ListNamespacesQuery(query)
+}
+
+// LoadBalancers runs the query against the load balancer enumeration.
+func (q Query) LoadBalancers() ([]HostComputeLoadBalancer, error) {
+	query, err := q.Build()
+	if err != nil {
+		return nil, err
+	}
+	return ListLoadBalancersQuery(query)
+}
+
+// Routes runs the query against the route enumeration.
+func (q Query) Routes() ([]HostComputeRoute, error) {
+	query, err := q.Build()
+	if err != nil {
+		return nil, err
+	}
+	return ListRoutesQuery(query)
+}
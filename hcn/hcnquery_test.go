@@ -0,0 +1,67 @@
+//go:build windows && integration
+// +build windows,integration
+
+package hcn
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestQueryBuildDefault(t *testing.T) {
+	query, err := NewQuery().Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	queryJSON, err := json.Marshal(query)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `{"SchemaVersion":{"Major":2,"Minor":0}}`
+	if string(queryJSON) != expected {
+		t.Fatalf("expected %s, got %s", expected, queryJSON)
+	}
+}
+
+func TestQueryBuildWithFilterAndFlags(t *testing.T) {
+	query, err := NewQuery().
+		WithSchemaVersion(2, 2).
+		WithFlags(HostComputeQueryFlagsDetailed).
+		WithFilter("Name", "test-network").
+		Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	queryJSON, err := json.Marshal(query)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `{"SchemaVersion":{"Major":2,"Minor":2},"Flags":1,"Filter":"{\"Name\":\"test-network\"}"}`
+	if string(queryJSON) != expected {
+		t.Fatalf("expected %s, got %s", expected, queryJSON)
+	}
+}
+
+func TestQueryReuseDoesNotShareFilter(t *testing.T) {
+	base := NewQuery().WithFilter("Name", "a")
+	derived := base.WithFilter("VirtualNetwork", "b")
+
+	baseQuery, err := base.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if baseQuery.Filter != `{"Name":"a"}` {
+		t.Fatalf("base query filter changed: %s", baseQuery.Filter)
+	}
+	derivedQuery, err := derived.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var filter map[string]string
+	if err := json.Unmarshal([]byte(derivedQuery.Filter), &filter); err != nil {
+		t.Fatal(err)
+	}
+	if filter["Name"] != "a" || filter["VirtualNetwork"] != "b" {
+		t.Fatalf("derived query filter missing keys: %s", derivedQuery.Filter)
+	}
+}
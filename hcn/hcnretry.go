@@ -0,0 +1,67 @@
+//go:build windows
+
+package hcn
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"golang.org/x/sys/windows"
+)
+
+// defaultTransientCodes are the failure codes WithRetry treats as transient
+// when the caller does not supply their own. They cover the window during
+// node boot when the HNS service is still initializing: objects it has not
+// finished loading report as not found, and calls that race service startup
+// fail at the RPC layer.
+var defaultTransientCodes = []ErrorCode{
+	ERROR_NOT_FOUND,
+	HCN_E_NETWORK_NOT_FOUND,
+	ErrorCode(windows.RPC_S_SERVER_UNAVAILABLE),
+	ErrorCode(windows.RPC_S_CALL_FAILED),
+}
+
+// RetryOptions tune WithRetry. The zero value retries the default transient
+// codes with the package's default backoff.
+type RetryOptions struct {
+	// PollOptions set the delay between attempts, growing exponentially
+	// from Interval up to MaxInterval with jitter.
+	PollOptions
+	// TransientCodes overrides the set of failure codes that are retried.
+	// nil means defaultTransientCodes; an explicit empty slice retries
+	// nothing.
+	TransientCodes []ErrorCode
+}
+
+// WithRetry invokes fn until it succeeds, fails with a non-transient error,
+// or ctx expires, backing off exponentially with jitter between attempts.
+// Only failures whose code is in the transient set are retried; everything
+// else is returned as-is on the first occurrence. When ctx expires while the
+// operation is still failing transiently, the returned error carries both
+// ctx's error and the last transient failure, so errors.Is works against
+// either.
+func WithRetry(ctx context.Context, opts RetryOptions, fn func() error) error {
+	codes := opts.TransientCodes
+	if codes == nil {
+		codes = defaultTransientCodes
+	}
+
+	var lastErr error
+	pollErr := pollUntil(ctx, opts.PollOptions, func() (bool, error) {
+		lastErr = fn()
+		if lastErr == nil {
+			return true, nil
+		}
+		for _, code := range codes {
+			if CheckErrorWithCode(lastErr, code) {
+				return false, nil
+			}
+		}
+		return false, lastErr
+	})
+	if pollErr != nil && lastErr != nil && ctx.Err() != nil && errors.Is(pollErr, ctx.Err()) {
+		return fmt.Errorf("%w; last transient error: %w", pollErr, lastErr)
+	}
+	return pollErr
+}
@@ -5,9 +5,11 @@ package hcn
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
+	"net"
+	"net/netip"
 
 	"github.com/Microsoft/go-winio/pkg/guid"
-	"github.com/Microsoft/hcsshim/internal/interop"
 	"github.com/sirupsen/logrus"
 )
 
@@ -43,8 +45,24 @@ func ListRoutesQuery(query HostComputeQuery) ([]HostComputeRoute, error) {
 	return routes, nil
 }
 
+// ListRouteIDs makes a call to query the list of available routes and
+// returns their GUIDs. Unlike ListRoutesQuery, it does not open and query
+// each route, making it much cheaper for existence checks.
+func ListRouteIDs(query HostComputeQuery) ([]guid.GUID, error) {
+	queryJSON, err := json.Marshal(query)
+	if err != nil {
+		return nil, err
+	}
+
+	return enumerateRouteIds(string(queryJSON))
+}
+
 // GetRouteByID returns the route specified by Id.
 func GetRouteByID(routeID string) (*HostComputeRoute, error) {
+	routeID, err := normalizeGUIDString("routeID", routeID)
+	if err != nil {
+		return nil, err
+	}
 	hcnQuery := defaultQuery()
 	mapA := map[string]string{"ID": routeID}
 	filter, err := json.Marshal(mapA)
@@ -64,10 +82,17 @@ func GetRouteByID(routeID string) (*HostComputeRoute, error) {
 }
 
 // Create Route.
-func (route *HostComputeRoute) Create() (*HostComputeRoute, error) {
+func (route *HostComputeRoute) Create(options ...CallOption) (*HostComputeRoute, error) {
 	logrus.Debugf("hcn::HostComputeRoute::Create id=%s", route.ID)
 
-	jsonString, err := json.Marshal(route)
+	callOpts := resolveCallOptions(options)
+	settings := *route
+	if err := callOpts.applySchemaVersion(&settings.SchemaVersion); err != nil {
+		return nil, err
+	}
+	applyDefaultSchemaVersion(ObjectTypeRoute, &settings.SchemaVersion)
+
+	jsonString, err := json.Marshal(&settings)
 	if err != nil {
 		return nil, err
 	}
@@ -95,6 +120,63 @@ func (route *HostComputeRoute) Delete() error {
 	return nil
 }
 
+// maxRouteMetric is the largest metric a route can carry, matching the limit
+// the OS enforces for interface route metrics.
+const maxRouteMetric = 9999
+
+// Update changes the route's next hop and metric in place via hcnModifyRoute,
+// so SDN route attributes can change without delete and recreate (and without
+// the route losing its ID). When both values already match, no modify syscall
+// is issued.
+func (route *HostComputeRoute) Update(nextHop string, metric uint32) error {
+	logrus.Debugf("hcn::HostComputeRoute::Update id=%s nextHop=%s metric=%d", route.ID, nextHop, metric)
+
+	if net.ParseIP(nextHop) == nil {
+		return fmt.Errorf("invalid next hop address %q", nextHop)
+	}
+	if metric > maxRouteMetric {
+		return fmt.Errorf("route metric %d exceeds maximum %d", metric, maxRouteMetric)
+	}
+
+	current, err := GetRouteByID(route.ID)
+	if err != nil {
+		return err
+	}
+	changed := false
+	for i := range current.Setting {
+		if current.Setting[i].NextHop != nextHop || current.Setting[i].Metric != metric {
+			current.Setting[i].NextHop = nextHop
+			current.Setting[i].Metric = metric
+			changed = true
+		}
+	}
+	if !changed {
+		route.Setting = current.Setting
+		return nil
+	}
+
+	settingsJSON, err := json.Marshal(current)
+	if err != nil {
+		return err
+	}
+	updated, err := modifyRoute(route.ID, string(settingsJSON))
+	if err != nil {
+		return err
+	}
+	*route = *updated
+	return nil
+}
+
+// Properties re-queries the route from the service and returns its current
+// SDN route settings.
+func (route *HostComputeRoute) Properties() ([]SDNRoutePolicySetting, error) {
+	current, err := GetRouteByID(route.ID)
+	if err != nil {
+		return nil, err
+	}
+	return current.Setting, nil
+}
+
 // AddEndpoint add an endpoint to a route
 // Since HCNRoute doesn't implement modify functionality, add operation is essentially delete and add
 func (route *HostComputeRoute) AddEndpoint(endpoint *HostComputeEndpoint) (*HostComputeRoute, error) {
@@ -134,6 +216,44 @@ func (route *HostComputeRoute) RemoveEndpoint(endpoint *HostComputeEndpoint) (*H
 	return route.Create()
 }
 
+// NewRoute builds a HostComputeRoute from typed values, validating them up
+// front so malformed routes fail with a descriptive error here instead of an
+// opaque one deep inside HCN. The destination must be a well-formed prefix
+// with no host bits set, the next hop must be a unicast address of the same
+// family, and the metric must not exceed the OS limit. The returned route
+// still needs endpoints attached (AddEndpoint) before Create.
+func NewRoute(destination netip.Prefix, nextHop netip.Addr, metric uint16) (*HostComputeRoute, error) {
+	if !destination.IsValid() {
+		return nil, fmt.Errorf("invalid destination prefix %v", destination)
+	}
+	if destination != destination.Masked() {
+		return nil, fmt.Errorf("destination prefix %s has host bits set; use %s", destination, destination.Masked())
+	}
+	if !nextHop.IsValid() {
+		return nil, fmt.Errorf("invalid next hop address %v", nextHop)
+	}
+	if nextHop.IsUnspecified() || nextHop.IsMulticast() {
+		return nil, fmt.Errorf("next hop %s is not a unicast address", nextHop)
+	}
+	if destination.Addr().Is4() != nextHop.Is4() {
+		return nil, fmt.Errorf("destination %s and next hop %s are different address families", destination, nextHop)
+	}
+	if metric > maxRouteMetric {
+		return nil, fmt.Errorf("route metric %d exceeds maximum %d", metric, maxRouteMetric)
+	}
+
+	return &HostComputeRoute{
+		SchemaVersion: V2SchemaVersion(),
+		Setting: []SDNRoutePolicySetting{
+			{
+				DestinationPrefix: destination.String(),
+				NextHop:           nextHop.String(),
+				Metric:            uint32(metric),
+			},
+		},
+	}, nil
+}
+
 // AddRoute for the specified endpoints and SDN Route setting
 func AddRoute(endpoints []HostComputeEndpoint, destinationPrefix string, nextHop string, needEncapsulation bool) (*HostComputeRoute, error) {
 	logrus.Debugf("hcn::HostComputeRoute::AddRoute endpointId=%v, destinationPrefix=%v, nextHop=%v, needEncapsulation=%v", endpoints, destinationPrefix, nextHop, needEncapsulation)
@@ -160,20 +280,29 @@ func AddRoute(endpoints []HostComputeEndpoint, destinationPrefix string, nextHop
 	return route.Create()
 }
 
-func enumerateRoutes(query string) ([]HostComputeRoute, error) {
+func enumerateRouteIds(query string) ([]guid.GUID, error) {
+	defer acquireSyscallSlot()()
 	// Enumerate all routes Guids
 	var (
 		resultBuffer *uint16
 		routeBuffer  *uint16
 	)
+	callStart := traceStart()
 	hr := hcnEnumerateRoutes(query, &routeBuffer, &resultBuffer)
-	if err := checkForErrors("hcnEnumerateRoutes", hr, resultBuffer); err != nil {
+	if err := traceEnd("hcnEnumerateRoutes", callStart, checkForErrors("hcnEnumerateRoutes", hr, &resultBuffer)); err != nil {
 		return nil, err
 	}
 
-	routes := interop.ConvertAndFreeCoTaskMemString(routeBuffer)
-	var routeIds []guid.GUID
-	if err := json.Unmarshal([]byte(routes), &routeIds); err != nil {
+	routes, err := decodeAndFree(routeBuffer)
+	if err != nil {
+		return nil, err
+	}
+	return decodeGUIDList(routes)
+}
+
+func enumerateRoutes(query string) ([]HostComputeRoute, error) {
+	routeIds, err := enumerateRouteIds(query)
+	if err != nil {
 		return nil, err
 	}
 
@@ -189,25 +318,32 @@ func enumerateRoutes(query string) ([]HostComputeRoute, error) {
 }
 
 func getRoute(routeGUID guid.GUID, query string) (*HostComputeRoute, error) {
+	defer acquireSyscallSlot()()
 	// Open routes.
 	var (
 		routeHandle      hcnRoute
 		resultBuffer     *uint16
 		propertiesBuffer *uint16
 	)
+	callStart := traceStart()
 	hr := hcnOpenRoute(&routeGUID, &routeHandle, &resultBuffer)
-	if err := checkForErrors("hcnOpenRoute", hr, resultBuffer); err != nil {
+	if err := traceEnd("hcnOpenRoute", callStart, checkForErrors("hcnOpenRoute", hr, &resultBuffer)); err != nil {
 		return nil, err
 	}
 	// Query routes.
+	callStart = traceStart()
 	hr = hcnQueryRouteProperties(routeHandle, query, &propertiesBuffer, &resultBuffer)
-	if err := checkForErrors("hcnQueryRouteProperties", hr, resultBuffer); err != nil {
+	if err := traceEnd("hcnQueryRouteProperties", callStart, checkForErrors("hcnQueryRouteProperties", hr, &resultBuffer)); err != nil {
+		return nil, err
+	}
+	properties, err := decodeAndFree(propertiesBuffer)
+	if err != nil {
 		return nil, err
 	}
-	properties := interop.ConvertAndFreeCoTaskMemString(propertiesBuffer)
 	// Close routes.
+	callStart = traceStart()
 	hr = hcnCloseRoute(routeHandle)
-	if err := checkForErrors("hcnCloseRoute", hr, nil); err != nil {
+	if err := traceEnd("hcnCloseRoute", callStart, checkForErrors("hcnCloseRoute", hr, nil)); err != nil {
 		return nil, err
 	}
 	// Convert output to HostComputeRoute
@@ -219,6 +355,10 @@ func getRoute(routeGUID guid.GUID, query string) (*HostComputeRoute, error) {
 }
 
 func createRoute(settings string) (*HostComputeRoute, error) {
+	defer acquireSyscallSlot()()
+	if err := checkSettings("hcnCreateRoute", settings); err != nil {
+		return nil, err
+	}
 	// Create new route.
 	var (
 		routeHandle      hcnRoute
@@ -226,8 +366,9 @@ func createRoute(settings string) (*HostComputeRoute, error) {
 		propertiesBuffer *uint16
 	)
 	routeGUID := guid.GUID{}
+	callStart := traceStart()
 	hr := hcnCreateRoute(&routeGUID, settings, &routeHandle, &resultBuffer)
-	if err := checkForErrors("hcnCreateRoute", hr, resultBuffer); err != nil {
+	if err := traceEnd("hcnCreateRoute", callStart, checkForErrors("hcnCreateRoute", hr, &resultBuffer)); err != nil {
 		return nil, err
 	}
 	// Query route.
@@ -236,14 +377,74 @@ func createRoute(settings string) (*HostComputeRoute, error) {
 	if err != nil {
 		return nil, err
 	}
+	callStart = traceStart()
 	hr = hcnQueryRouteProperties(routeHandle, string(query), &propertiesBuffer, &resultBuffer)
-	if err := checkForErrors("hcnQueryRouteProperties", hr, resultBuffer); err != nil {
+	if err := traceEnd("hcnQueryRouteProperties", callStart, checkForErrors("hcnQueryRouteProperties", hr, &resultBuffer)); err != nil {
+		return nil, err
+	}
+	properties, err := decodeAndFree(propertiesBuffer)
+	if err != nil {
 		return nil, err
 	}
-	properties := interop.ConvertAndFreeCoTaskMemString(propertiesBuffer)
 	// Close Route.
+	callStart = traceStart()
+	hr = hcnCloseRoute(routeHandle)
+	if err := traceEnd("hcnCloseRoute", callStart, checkForErrors("hcnCloseRoute", hr, nil)); err != nil {
+		return nil, err
+	}
+	// Convert output to HostComputeRoute
+	var outputRoute HostComputeRoute
+	if err := json.Unmarshal([]byte(properties), &outputRoute); err != nil {
+		return nil, err
+	}
+	return &outputRoute, nil
+}
+
+func modifyRoute(routeID string, settings string) (*HostComputeRoute, error) {
+	defer acquireSyscallSlot()()
+	if err := checkSettings("hcnModifyRoute", settings); err != nil {
+		return nil, err
+	}
+	routeGUID, err := guid.FromString(routeID)
+	if err != nil {
+		return nil, errInvalidRouteID
+	}
+	// Open route.
+	var (
+		routeHandle      hcnRoute
+		resultBuffer     *uint16
+		propertiesBuffer *uint16
+	)
+	callStart := traceStart()
+	hr := hcnOpenRoute(&routeGUID, &routeHandle, &resultBuffer)
+	if err := traceEnd("hcnOpenRoute", callStart, checkForErrors("hcnOpenRoute", hr, &resultBuffer)); err != nil {
+		return nil, err
+	}
+	// Modify route.
+	callStart = traceStart()
+	hr = hcnModifyRoute(routeHandle, settings, &resultBuffer)
+	if err := traceEnd("hcnModifyRoute", callStart, checkForErrors("hcnModifyRoute", hr, &resultBuffer)); err != nil {
+		return nil, err
+	}
+	// Query route.
+	hcnQuery := defaultQuery()
+	query, err := json.Marshal(hcnQuery)
+	if err != nil {
+		return nil, err
+	}
+	callStart = traceStart()
+	hr = hcnQueryRouteProperties(routeHandle, string(query), &propertiesBuffer, &resultBuffer)
+	if err := traceEnd("hcnQueryRouteProperties", callStart, checkForErrors("hcnQueryRouteProperties", hr, &resultBuffer)); err != nil {
+		return nil, err
+	}
+	properties, err := decodeAndFree(propertiesBuffer)
+	if err != nil {
+		return nil, err
+	}
+	// Close route.
+	callStart = traceStart()
 	hr = hcnCloseRoute(routeHandle)
-	if err := checkForErrors("hcnCloseRoute", hr, nil); err != nil {
+	if err := traceEnd("hcnCloseRoute", callStart, checkForErrors("hcnCloseRoute", hr, nil)); err != nil {
 		return nil, err
 	}
 	// Convert output to HostComputeRoute
@@ -255,13 +456,15 @@ func createRoute(settings string) (*HostComputeRoute, error) {
 }
 
 func deleteRoute(routeID string) error {
+	defer acquireSyscallSlot()()
 	routeGUID, err := guid.FromString(routeID)
 	if err != nil {
 		return errInvalidRouteID
 	}
 	var resultBuffer *uint16
+	callStart := traceStart()
 	hr := hcnDeleteRoute(&routeGUID, &resultBuffer)
-	if err := checkForErrors("hcnDeleteRoute", hr, resultBuffer); err != nil {
+	if err := traceEnd("hcnDeleteRoute", callStart, checkForErrors("hcnDeleteRoute", hr, &resultBuffer)); err != nil {
 		return err
 	}
 	return nil
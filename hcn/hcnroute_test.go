@@ -5,6 +5,7 @@ package hcn
 
 import (
 	"encoding/json"
+	"net/netip"
 	"testing"
 )
 
@@ -170,3 +171,43 @@ func TestAddRoute(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+func TestNewRouteIPv4(t *testing.T) {
+	route, err := NewRoute(netip.MustParsePrefix("10.0.0.0/16"), netip.MustParseAddr("10.1.0.1"), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(route.Setting) != 1 {
+		t.Fatalf("expected one route setting, got %d", len(route.Setting))
+	}
+	setting := route.Setting[0]
+	if setting.DestinationPrefix != "10.0.0.0/16" || setting.NextHop != "10.1.0.1" || setting.Metric != 10 {
+		t.Fatalf("unexpected route setting: %+v", setting)
+	}
+}
+
+func TestNewRouteIPv6(t *testing.T) {
+	route, err := NewRoute(netip.MustParsePrefix("fd00:1::/64"), netip.MustParseAddr("fd00::1"), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	setting := route.Setting[0]
+	if setting.DestinationPrefix != "fd00:1::/64" || setting.NextHop != "fd00::1" {
+		t.Fatalf("unexpected route setting: %+v", setting)
+	}
+}
+
+func TestNewRouteValidation(t *testing.T) {
+	if _, err := NewRoute(netip.MustParsePrefix("10.0.0.1/16"), netip.MustParseAddr("10.1.0.1"), 1); err == nil {
+		t.Fatal("expected destination with host bits set to be rejected")
+	}
+	if _, err := NewRoute(netip.MustParsePrefix("10.0.0.0/16"), netip.MustParseAddr("fd00::1"), 1); err == nil {
+		t.Fatal("expected mixed address families to be rejected")
+	}
+	if _, err := NewRoute(netip.MustParsePrefix("10.0.0.0/16"), netip.MustParseAddr("0.0.0.0"), 1); err == nil {
+		t.Fatal("expected unspecified next hop to be rejected")
+	}
+	if _, err := NewRoute(netip.MustParsePrefix("10.0.0.0/16"), netip.MustParseAddr("10.1.0.1"), 60000); err == nil {
+		t.Fatal("expected out-of-range metric to be rejected")
+	}
+}
@@ -0,0 +1,69 @@
+//go:build windows
+
+package hcn
+
+import (
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ObjectType identifies an HCN object class, for per-type schema version
+// defaults.
+type ObjectType string
+
+const (
+	ObjectTypeNetwork      ObjectType = "Network"
+	ObjectTypeEndpoint     ObjectType = "Endpoint"
+	ObjectTypeNamespace    ObjectType = "Namespace"
+	ObjectTypeLoadBalancer ObjectType = "LoadBalancer"
+	ObjectTypeRoute        ObjectType = "Route"
+)
+
+var (
+	defaultSchemaVersionsOnce sync.Once
+	defaultSchemaVersions     map[ObjectType]SchemaVersion
+)
+
+// probeDefaultSchemaVersions chooses, per object type, the newest schema
+// version the running service supports for that type. Networks exist in both
+// the V1 and V2 APIs, so they take the newest version the service reports;
+// the other object classes only exist in V2 and always default to its schema
+// version — creating them on a V1-only host fails at the service regardless
+// of the version marshaled.
+func probeDefaultSchemaVersions() {
+	newest := V2SchemaVersion()
+	if supported, err := SupportedSchemaVersions(); err != nil {
+		logrus.Debugf("hcn::probeDefaultSchemaVersions probe failed, defaulting to V2: %s", err)
+	} else if len(supported) > 0 {
+		newest = supported[len(supported)-1]
+	}
+
+	defaultSchemaVersions = map[ObjectType]SchemaVersion{
+		ObjectTypeNetwork:      newest,
+		ObjectTypeEndpoint:     V2SchemaVersion(),
+		ObjectTypeNamespace:    V2SchemaVersion(),
+		ObjectTypeLoadBalancer: V2SchemaVersion(),
+		ObjectTypeRoute:        V2SchemaVersion(),
+	}
+}
+
+// DefaultSchemaVersionFor returns the schema version Create marshals for the
+// object type when the caller leaves SchemaVersion unset and passes no
+// WithSchemaVersion option. The defaults are probed from the service's
+// capabilities once and cached.
+func DefaultSchemaVersionFor(objType ObjectType) SchemaVersion {
+	defaultSchemaVersionsOnce.Do(probeDefaultSchemaVersions)
+	if version, ok := defaultSchemaVersions[objType]; ok {
+		return version
+	}
+	return V2SchemaVersion()
+}
+
+// applyDefaultSchemaVersion fills sv with the object type's default when the
+// caller left it zero.
+func applyDefaultSchemaVersion(objType ObjectType, sv *SchemaVersion) {
+	if (*sv == SchemaVersion{}) {
+		*sv = DefaultSchemaVersionFor(objType)
+	}
+}
@@ -0,0 +1,127 @@
+//go:build windows
+
+package hcn
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/Microsoft/go-winio/pkg/guid"
+	"github.com/sirupsen/logrus"
+)
+
+// EndpointAlreadySharedError results from sharing an endpoint into a
+// compartment that already has it.
+type EndpointAlreadySharedError struct {
+	EndpointID    guid.GUID
+	CompartmentID uint32
+}
+
+var _ error = EndpointAlreadySharedError{}
+
+func (e EndpointAlreadySharedError) Error() string {
+	return fmt.Sprintf("Endpoint %q is already shared into compartment %d", e.EndpointID, e.CompartmentID)
+}
+
+// EndpointNotSharedError results from unsharing an endpoint from a
+// compartment it was not shared into.
+type EndpointNotSharedError struct {
+	EndpointID    guid.GUID
+	CompartmentID uint32
+}
+
+var _ error = EndpointNotSharedError{}
+
+func (e EndpointNotSharedError) Error() string {
+	return fmt.Sprintf("Endpoint %q is not shared into compartment %d", e.EndpointID, e.CompartmentID)
+}
+
+// sharedEndpoints tracks, per endpoint, the compartments it has been shared
+// into through ShareEndpoint, so deletion can be refused while references
+// remain. The registry is process-local: shares made by other processes are
+// not visible here.
+var (
+	sharedEndpointsMu sync.Mutex
+	sharedEndpoints   = make(map[guid.GUID]map[uint32]struct{})
+)
+
+// sharedCompartmentCount returns how many compartments the endpoint is
+// currently shared into.
+func sharedCompartmentCount(endpointID guid.GUID) int {
+	sharedEndpointsMu.Lock()
+	defer sharedEndpointsMu.Unlock()
+	return len(sharedEndpoints[endpointID])
+}
+
+// compartmentShareSetting is the settings document for compartment share
+// modify requests.
+type compartmentShareSetting struct {
+	CompartmentId uint32 `json:",omitempty"`
+}
+
+// ShareEndpoint shares an endpoint into an additional network compartment via
+// the modify path and records the reference, so the endpoint cannot be
+// deleted through this package while shares remain. Sharing into a
+// compartment that already has the endpoint returns
+// EndpointAlreadySharedError without calling HNS.
+func ShareEndpoint(endpointID guid.GUID, compartmentID uint32) error {
+	logrus.Debugf("hcn::HostComputeEndpoint::ShareEndpoint id=%s compartment=%d", endpointID, compartmentID)
+
+	sharedEndpointsMu.Lock()
+	defer sharedEndpointsMu.Unlock()
+	if _, ok := sharedEndpoints[endpointID][compartmentID]; ok {
+		return EndpointAlreadySharedError{EndpointID: endpointID, CompartmentID: compartmentID}
+	}
+
+	settingsJSON, err := json.Marshal(compartmentShareSetting{CompartmentId: compartmentID})
+	if err != nil {
+		return err
+	}
+	requestMessage := &ModifyEndpointSettingRequest{
+		ResourceType: EndpointResourceTypeCompartment,
+		RequestType:  RequestTypeAdd,
+		Settings:     settingsJSON,
+	}
+	if err := ModifyEndpointSettings(endpointID.String(), requestMessage); err != nil {
+		return err
+	}
+
+	if sharedEndpoints[endpointID] == nil {
+		sharedEndpoints[endpointID] = make(map[uint32]struct{})
+	}
+	sharedEndpoints[endpointID][compartmentID] = struct{}{}
+	return nil
+}
+
+// UnshareEndpoint removes an endpoint from a compartment it was shared into
+// and drops the reference. Unsharing from a compartment that does not have
+// the endpoint returns EndpointNotSharedError without calling HNS.
+func UnshareEndpoint(endpointID guid.GUID, compartmentID uint32) error {
+	logrus.Debugf("hcn::HostComputeEndpoint::UnshareEndpoint id=%s compartment=%d", endpointID, compartmentID)
+
+	sharedEndpointsMu.Lock()
+	defer sharedEndpointsMu.Unlock()
+	if _, ok := sharedEndpoints[endpointID][compartmentID]; !ok {
+		return EndpointNotSharedError{EndpointID: endpointID, CompartmentID: compartmentID}
+	}
+
+	settingsJSON, err := json.Marshal(compartmentShareSetting{CompartmentId: compartmentID})
+	if err != nil {
+		return err
+	}
+	requestMessage := &ModifyEndpointSettingRequest{
+		ResourceType: EndpointResourceTypeCompartment,
+		RequestType:  RequestTypeRemove,
+		Settings:     settingsJSON,
+	}
+	if err := ModifyEndpointSettings(endpointID.String(), requestMessage); err != nil {
+		return err
+	}
+
+	delete(sharedEndpoints[endpointID], compartmentID)
+	if len(sharedEndpoints[endpointID]) == 0 {
+		delete(sharedEndpoints, endpointID)
+	}
+	return nil
+}
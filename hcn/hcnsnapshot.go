@@ -0,0 +1,87 @@
+//go:build windows
+
+package hcn
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// SnapshotSection describes how the enumeration of one object class went:
+// when it finished, whether it ran to completion, and the error that stopped
+// it otherwise. A section skipped because the context expired has
+// Err == ctx.Err(), so dashboards can distinguish "partial (cancelled)" from
+// an HNS failure.
+type SnapshotSection struct {
+	CapturedAt time.Time
+	Complete   bool
+	Err        error
+}
+
+// StateSnapshot is a point-in-time capture of the HCN object classes, for
+// diagnostics. Each object class carries its own section metadata because the
+// enumerations run one after another and the context can expire between them.
+type StateSnapshot struct {
+	Networks      []HostComputeNetwork
+	Endpoints     []HostComputeEndpoint
+	Namespaces    []HostComputeNamespace
+	LoadBalancers []HostComputeLoadBalancer
+	Routes        []HostComputeRoute
+	// Sections holds per-object-class capture metadata, keyed by the field
+	// name above ("Networks", "Endpoints", ...).
+	Sections map[string]SnapshotSection
+	// Partial reports whether any section is incomplete, for any reason.
+	Partial bool
+}
+
+// Snapshot enumerates every HCN object class and returns the result with
+// staleness metadata. It always returns a snapshot: if the context expires or
+// an enumeration fails partway through, the classes captured so far are kept,
+// the affected sections are marked incomplete, and the snapshot is flagged
+// partial. The returned error joins whatever cut the capture short.
+func Snapshot(ctx context.Context) (*StateSnapshot, error) {
+	snapshot := &StateSnapshot{Sections: make(map[string]SnapshotSection)}
+	var errs []error
+
+	capture := func(name string, enumerate func() error) {
+		section := SnapshotSection{}
+		if err := ctx.Err(); err != nil {
+			section.Err = err
+		} else if err := enumerate(); err != nil {
+			section.Err = fmt.Errorf("%s: %w", name, err)
+		} else {
+			section.Complete = true
+		}
+		section.CapturedAt = time.Now()
+		snapshot.Sections[name] = section
+		if !section.Complete {
+			snapshot.Partial = true
+			errs = append(errs, section.Err)
+		}
+	}
+
+	capture("Networks", func() (err error) {
+		snapshot.Networks, err = ListNetworks()
+		return err
+	})
+	capture("Endpoints", func() (err error) {
+		snapshot.Endpoints, err = ListEndpoints()
+		return err
+	})
+	capture("Namespaces", func() (err error) {
+		snapshot.Namespaces, err = ListNamespaces()
+		return err
+	})
+	capture("LoadBalancers", func() (err error) {
+		snapshot.LoadBalancers, err = ListLoadBalancers()
+		return err
+	})
+	capture("Routes", func() (err error) {
+		snapshot.Routes, err = ListRoutes()
+		return err
+	})
+
+	return snapshot, errors.Join(errs...)
+}
@@ -0,0 +1,39 @@
+//go:build windows
+
+package hcn
+
+import (
+	"github.com/Microsoft/hcsshim/internal/hns"
+	"github.com/sirupsen/logrus"
+)
+
+// VirtualSwitchInfo describes a vSwitch HNS manages: its name, the switch
+// type (External, Internal, Private), and the network adapter the switch is
+// bound to, if any.
+type VirtualSwitchInfo struct {
+	Name               string
+	Type               string
+	NetworkAdapterName string
+}
+
+// ListVirtualSwitches returns the vSwitches HNS manages on this host. Callers
+// creating Transparent or L2Bridge networks can check the NetworkAdapterName
+// they intend to use against the adapters already claimed by a switch before
+// asking HNS to create the network.
+func ListVirtualSwitches() ([]VirtualSwitchInfo, error) {
+	logrus.Debugf("hcn::ListVirtualSwitches")
+
+	hnsSwitches, err := hns.HNSListVirtualSwitchRequest()
+	if err != nil {
+		return nil, err
+	}
+	switches := make([]VirtualSwitchInfo, 0, len(hnsSwitches))
+	for _, hnsSwitch := range hnsSwitches {
+		switches = append(switches, VirtualSwitchInfo{
+			Name:               hnsSwitch.Name,
+			Type:               hnsSwitch.Type,
+			NetworkAdapterName: hnsSwitch.NetworkAdapterName,
+		})
+	}
+	return switches, nil
+}
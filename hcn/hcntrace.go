@@ -0,0 +1,53 @@
+//go:build windows
+
+package hcn
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Tracer observes every HCN syscall this package issues. It receives the
+// procedure name, the elapsed time in nanoseconds, and the error the call
+// produced (nil on success), after the call has completed. It may be invoked
+// concurrently and must not block; slow tracers slow every HCN call down.
+type Tracer func(op string, durationNs int64, err error)
+
+var tracer atomic.Pointer[Tracer]
+
+// SetTracer installs fn as the process-wide syscall tracer, replacing any
+// previous one; nil removes it. This is the hook for attaching structured
+// logs or telemetry spans to individual HCN calls — for example to find
+// which hcnModifyEndpoint invocations are slow — without recompiling.
+// When no tracer is set the per-call cost is a single nil check.
+func SetTracer(fn Tracer) {
+	if fn == nil {
+		tracer.Store(nil)
+		return
+	}
+	tracer.Store(&fn)
+}
+
+// traceStart marks the start of one syscall. It returns the zero time when
+// no tracer is installed, which traceEnd treats as tracing disabled, so the
+// unset path never reads the clock.
+func traceStart() time.Time {
+	if tracer.Load() == nil {
+		return time.Time{}
+	}
+	return time.Now()
+}
+
+// traceEnd reports one completed syscall to the tracer and returns err
+// unchanged, so call sites can wrap their existing error check in a single
+// expression. A tracer installed mid-call is not invoked for that call,
+// since no start time was taken for it.
+func traceEnd(op string, start time.Time, err error) error {
+	if start.IsZero() {
+		return err
+	}
+	if fn := tracer.Load(); fn != nil {
+		(*fn)(op, time.Since(start).Nanoseconds(), err)
+	}
+	return err
+}
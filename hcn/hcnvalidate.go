@@ -0,0 +1,140 @@
+//go:build windows
+
+package hcn
+
+import (
+	"errors"
+	"fmt"
+	"net/netip"
+)
+
+// overlayOnlyNetworkPolicies are network policy types that HNS only accepts
+// on Overlay networks.
+var overlayOnlyNetworkPolicies = []NetworkPolicyType{
+	DrMacAddress,
+	ProviderAddress,
+	RemoteSubnetRoute,
+	VxlanPort,
+}
+
+// knownNetworkTypes are the network types HNS recognizes.
+var knownNetworkTypes = []NetworkType{
+	NAT,
+	Transparent,
+	L2Bridge,
+	L2Tunnel,
+	ICS,
+	Private,
+	Overlay,
+}
+
+// ValidateNetworkSettings checks a network document for problems that would
+// make HNS reject it: subnet/route inconsistencies, invalid IPAM
+// configuration, policies incompatible with the network type, and an
+// infeasible schema version. All problems found are returned as a single
+// combined error rather than stopping at the first one.
+//
+// The checks are pure Go — no HNS calls are made — so they can run as a
+// pre-flight in CI as well as in the create path. A nil error means the
+// document is well-formed, not that the host will accept it; host-dependent
+// constraints (adapter names, address availability) are only checked by HNS
+// itself.
+func ValidateNetworkSettings(settings *HostComputeNetwork) error {
+	var errs []error
+
+	if settings.Type != "" {
+		known := false
+		for _, networkType := range knownNetworkTypes {
+			if settings.Type == networkType {
+				known = true
+				break
+			}
+		}
+		if !known {
+			errs = append(errs, fmt.Errorf("unknown network type %q", settings.Type))
+		}
+	}
+
+	for i, ipam := range settings.Ipams {
+		switch ipam.Type {
+		case "", "Static", "DHCP":
+		default:
+			errs = append(errs, fmt.Errorf("ipam %d has unknown type %q", i, ipam.Type))
+		}
+		if ipam.Type == "Static" && len(ipam.Subnets) == 0 {
+			errs = append(errs, fmt.Errorf("ipam %d is static but declares no subnets", i))
+		}
+		for j, subnet := range ipam.Subnets {
+			errs = append(errs, validateSubnet(i, j, subnet)...)
+		}
+	}
+
+	for _, policy := range settings.Policies {
+		for _, overlayOnly := range overlayOnlyNetworkPolicies {
+			if policy.Type == overlayOnly && settings.Type != Overlay {
+				errs = append(errs, fmt.Errorf("policy type %s requires an Overlay network, not %q", policy.Type, settings.Type))
+			}
+		}
+	}
+
+	sv := settings.SchemaVersion
+	if sv != (SchemaVersion{}) {
+		if sv.Major < 1 || sv.Major > 2 {
+			errs = append(errs, fmt.Errorf("schema version %d.%d is not a version HNS has ever supported", sv.Major, sv.Minor))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// validateSubnet checks one subnet's prefix and routes for internal
+// consistency. Indices identify the subnet in the combined error output.
+func validateSubnet(ipamIndex, subnetIndex int, subnet Subnet) []error {
+	var errs []error
+	where := fmt.Sprintf("ipam %d subnet %d", ipamIndex, subnetIndex)
+
+	var prefix netip.Prefix
+	havePrefix := false
+	if subnet.IpAddressPrefix != "" {
+		var err error
+		prefix, err = netip.ParsePrefix(subnet.IpAddressPrefix)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s has invalid address prefix %q: %w", where, subnet.IpAddressPrefix, err))
+		} else {
+			havePrefix = true
+		}
+	}
+
+	hasDefault := false
+	for k, route := range subnet.Routes {
+		if route.NextHop == "" {
+			errs = append(errs, fmt.Errorf("%s route %d has no gateway specified", where, k))
+			continue
+		}
+		nextHop, err := netip.ParseAddr(route.NextHop)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s route %d has invalid next hop %q: %w", where, k, route.NextHop, err))
+			continue
+		}
+		if route.DestinationPrefix != "" {
+			destination, err := netip.ParsePrefix(route.DestinationPrefix)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s route %d has invalid destination prefix %q: %w", where, k, route.DestinationPrefix, err))
+			} else {
+				if destination.Addr().Is4() != nextHop.Is4() {
+					errs = append(errs, fmt.Errorf("%s route %d mixes address families: destination %s, next hop %s", where, k, route.DestinationPrefix, route.NextHop))
+				}
+				if destination.Bits() == 0 {
+					hasDefault = true
+				}
+			}
+		}
+		if havePrefix && prefix.Addr().Is4() == nextHop.Is4() && !prefix.Contains(nextHop) {
+			errs = append(errs, fmt.Errorf("%s route %d next hop %s is outside the subnet %s", where, k, route.NextHop, subnet.IpAddressPrefix))
+		}
+	}
+	if havePrefix && !hasDefault {
+		errs = append(errs, fmt.Errorf("%s has an address prefix but no default gateway route", where))
+	}
+	return errs
+}
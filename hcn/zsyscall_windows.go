@@ -76,6 +76,7 @@ var (
 	procHcnQueryNamespaceProperties    = modcomputenetwork.NewProc("HcnQueryNamespaceProperties")
 	procHcnQueryNetworkProperties      = modcomputenetwork.NewProc("HcnQueryNetworkProperties")
 	procHcnQuerySdnRouteProperties     = modcomputenetwork.NewProc("HcnQuerySdnRouteProperties")
+	procGetCurrentThreadCompartmentId  = modiphlpapi.NewProc("GetCurrentThreadCompartmentId")
 	procSetCurrentThreadCompartmentId  = modiphlpapi.NewProc("SetCurrentThreadCompartmentId")
 	procHNSCall                        = modvmcompute.NewProc("HNSCall")
 )
@@ -785,6 +786,12 @@ func _hcnQueryRouteProperties(route hcnRoute, query *uint16, properties **uint16
 	return
 }
 
+func GetCurrentThreadCompartmentId() (id uint32) {
+	r0, _, _ := syscall.SyscallN(procGetCurrentThreadCompartmentId.Addr())
+	id = uint32(r0)
+	return
+}
+
 func SetCurrentThreadCompartmentId(compartmentId uint32) (hr error) {
 	r0, _, _ := syscall.SyscallN(procSetCurrentThreadCompartmentId.Addr(), uintptr(compartmentId))
 	if int32(r0) < 0 {
@@ -0,0 +1,27 @@
+//go:build windows
+
+package hns
+
+import (
+	"github.com/sirupsen/logrus"
+)
+
+// HNSVirtualSwitch represents a vSwitch managed by HNS.
+type HNSVirtualSwitch struct {
+	Id                 string `json:"ID,omitempty"`
+	Name               string `json:",omitempty"`
+	Type               string `json:",omitempty"`
+	NetworkAdapterName string `json:",omitempty"`
+}
+
+// HNSListVirtualSwitchRequest makes a HNS call to query the list of vSwitches
+func HNSListVirtualSwitchRequest() ([]HNSVirtualSwitch, error) {
+	var switches []HNSVirtualSwitch
+	err := hnsCall("GET", "/virtualswitches/", "", &switches)
+	if err != nil {
+		return nil, err
+	}
+
+	logrus.Debugf("Returned %d virtual switches", len(switches))
+	return switches, nil
+}
@@ -23,3 +23,8 @@ func Win32FromHresult(hr uintptr) syscall.Errno {
 	}
 	return syscall.Errno(hr)
 }
+
+// CoTaskMemFree frees a buffer allocated by the COM task allocator.
+func CoTaskMemFree(buffer unsafe.Pointer) {
+	coTaskMemFree(buffer)
+}
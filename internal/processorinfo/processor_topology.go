@@ -0,0 +1,66 @@
+//go:build windows
+
+package processorinfo
+
+import (
+	"context"
+	"sort"
+)
+
+// CoreInfo groups the logical processors that share one physical core. Cores
+// with more than one logical processor are SMT-enabled, and scheduling code
+// can use the grouping to avoid placing latency-sensitive work on SMT
+// siblings.
+type CoreInfo struct {
+	PackageID         uint32
+	CoreID            uint32
+	LogicalProcessors []uint32
+}
+
+// PackageInfo groups the physical cores of one processor package.
+type PackageInfo struct {
+	PackageID uint32
+	Cores     []CoreInfo
+}
+
+// HostProcessorTopology queries HCS for the host's processor information and
+// groups the logical processors by physical core and package. It is derived
+// from the same underlying query as HostProcessorInfo, which already reports
+// the core and package IDs per logical processor.
+func HostProcessorTopology(ctx context.Context) ([]PackageInfo, error) {
+	processorTopology, err := HostProcessorInfo(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	type coreKey struct {
+		packageID uint32
+		coreID    uint32
+	}
+	cores := make(map[coreKey][]uint32)
+	for _, lp := range processorTopology.LogicalProcessors {
+		key := coreKey{packageID: lp.PackageId, coreID: lp.CoreId}
+		cores[key] = append(cores[key], lp.LpIndex)
+	}
+
+	packages := make(map[uint32][]CoreInfo)
+	for key, lps := range cores {
+		sort.Slice(lps, func(i, j int) bool { return lps[i] < lps[j] })
+		packages[key.packageID] = append(packages[key.packageID], CoreInfo{
+			PackageID:         key.packageID,
+			CoreID:            key.coreID,
+			LogicalProcessors: lps,
+		})
+	}
+
+	var output []PackageInfo
+	for packageID, packageCores := range packages {
+		sort.Slice(packageCores, func(i, j int) bool { return packageCores[i].CoreID < packageCores[j].CoreID })
+		output = append(output, PackageInfo{
+			PackageID: packageID,
+			Cores:     packageCores,
+		})
+	}
+	sort.Slice(output, func(i, j int) bool { return output[i].PackageID < output[j].PackageID })
+	return output, nil
+}
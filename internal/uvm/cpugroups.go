@@ -6,15 +6,74 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strconv"
 
+	"github.com/Microsoft/go-winio/pkg/guid"
 	"github.com/Microsoft/hcsshim/internal/cpugroup"
 	"github.com/Microsoft/hcsshim/internal/hcs/resourcepaths"
 	hcsschema "github.com/Microsoft/hcsshim/internal/hcs/schema2"
+	"github.com/Microsoft/hcsshim/internal/processorinfo"
 	"github.com/Microsoft/hcsshim/osversion"
 )
 
 var errCPUGroupCreateNotSupported = fmt.Errorf("cpu group assignment on create requires a build of %d or higher", osversion.V21H1)
 
+// cpuGroupNumaNamespace is the GUID namespace used to derive deterministic
+// cpugroup IDs from NUMA node numbers, so pods pinned to the same node share
+// one group.
+var cpuGroupNumaNamespace = guid.GUID{
+	Data1: 0x8edd9619,
+	Data2: 0x9e4f,
+	Data3: 0x4c84,
+	Data4: [8]byte{0x96, 0x2e, 0xd2, 0x07, 0x56, 0xa7, 0x1c, 0x6b},
+}
+
+// CPUGroupForNumaNode returns the ID of a cpugroup containing every logical
+// processor of the given host NUMA node, creating the group if it does not
+// already exist. It returns an error when the node does not exist on this
+// host.
+func CPUGroupForNumaNode(ctx context.Context, node uint32) (string, error) {
+	topology, err := processorinfo.HostProcessorInfo(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to get host processor information: %w", err)
+	}
+	var lps []uint32
+	for _, lp := range topology.LogicalProcessors {
+		if uint32(lp.NodeNumber) == node {
+			lps = append(lps, lp.LpIndex)
+		}
+	}
+	if len(lps) == 0 {
+		return "", fmt.Errorf("NUMA node %d does not exist on this host", node)
+	}
+
+	id, err := guid.NewV5(cpuGroupNumaNamespace, []byte(fmt.Sprintf("numa-node-%d", node)))
+	if err != nil {
+		return "", err
+	}
+	if _, err := cpugroup.GetCPUGroupConfig(ctx, id.String()); err == nil {
+		// Group already exists, reuse it.
+		return id.String(), nil
+	}
+	if err := cpugroup.Create(ctx, id.String(), lps); err != nil {
+		return "", err
+	}
+	return id.String(), nil
+}
+
+// cpuGroupIDFromNumaNode resolves the CPUGroupNumaNode option to a concrete
+// cpugroup ID, validating that it is not combined with an explicit group ID.
+func cpuGroupIDFromNumaNode(ctx context.Context, opts *Options) (string, error) {
+	if opts.CPUGroupID != "" {
+		return "", errors.New("cannot specify both a cpugroup ID and a cpugroup NUMA node")
+	}
+	node, err := strconv.ParseUint(opts.CPUGroupNumaNode, 10, 32)
+	if err != nil {
+		return "", fmt.Errorf("invalid cpugroup NUMA node %q: %w", opts.CPUGroupNumaNode, err)
+	}
+	return CPUGroupForNumaNode(ctx, uint32(node))
+}
+
 // ReleaseCPUGroup unsets the cpugroup from the VM
 func (uvm *UtilityVM) ReleaseCPUGroup(ctx context.Context) error {
 	if err := uvm.unsetCPUGroup(ctx); err != nil {
@@ -28,6 +87,13 @@ func (uvm *UtilityVM) SetCPUGroup(ctx context.Context, id string) error {
 	if id == "" {
 		return errors.New("must specify an ID to use when configuring a VM's cpugroup")
 	}
+	// A running VM must leave its current cpugroup before it can join a
+	// different one, so re-homing is an unset followed by a set. Moving a VM
+	// that is not in a group to the null group is a no-op, so this is safe for
+	// first-time assignment as well.
+	if err := uvm.unsetCPUGroup(ctx); err != nil {
+		return fmt.Errorf("failed to remove VM %s from its current cpugroup: %w", uvm.ID(), err)
+	}
 	return uvm.setCPUGroup(ctx, id)
 }
 
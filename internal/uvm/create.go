@@ -84,6 +84,11 @@ type Options struct {
 	// CPUGroupID set the ID of a CPUGroup on the host that the UVM should be added to on start.
 	// Defaults to an empty string which indicates the UVM should not be added to any CPUGroup.
 	CPUGroupID string
+	// CPUGroupNumaNode is the host NUMA node whose logical processors the UVM's
+	// cpugroup should be built from. The group is created (or reused) at UVM
+	// creation. Defaults to an empty string which indicates no NUMA-pinned
+	// cpugroup. Cannot be combined with CPUGroupID.
+	CPUGroupNumaNode string
 	// NetworkConfigProxy holds the address of the network config proxy service.
 	// This != "" determines whether to start the ComputeAgent TTRPC service
 	// that receives the UVMs set of NICs from this proxy instead of enumerating
@@ -256,6 +256,13 @@ func fetchProcessor(ctx context.Context, opts *OptionsLCOW, uvm *UtilityVM) (*hc
 		Limit:  uint64(opts.ProcessorLimit),
 		Weight: uint64(opts.ProcessorWeight),
 	}
+	if opts.CPUGroupNumaNode != "" {
+		id, err := cpuGroupIDFromNumaNode(ctx, opts.Options)
+		if err != nil {
+			return nil, err
+		}
+		opts.CPUGroupID = id
+	}
 	// We can set a cpu group for the VM at creation time in recent builds.
 	if opts.CPUGroupID != "" {
 		if osversion.Build() < osversion.V21H1 {
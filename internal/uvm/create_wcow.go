@@ -175,6 +175,13 @@ func prepareConfigDoc(ctx context.Context, uvm *UtilityVM, opts *OptionsWCOW) (*
 		processor.NumaProcessorsSettings = numaProcessors
 	}
 
+	if opts.CPUGroupNumaNode != "" {
+		id, err := cpuGroupIDFromNumaNode(ctx, opts.Options)
+		if err != nil {
+			return nil, err
+		}
+		opts.CPUGroupID = id
+	}
 	// We can set a cpu group for the VM at creation time in recent builds.
 	if opts.CPUGroupID != "" {
 		if osversion.Build() < osversion.V21H1 {
@@ -6,6 +6,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net"
 	"os"
 	"slices"
 	"strings"
@@ -720,6 +721,39 @@ func (uvm *UtilityVM) UpdateNIC(ctx context.Context, id string, settings *hcssch
 	return uvm.modify(ctx, req)
 }
 
+// UpdateGuestDNS makes a guest request to update the DNS configuration of a
+// network adapter inside a running utility VM. This is separate from the
+// host-side endpoint DNS settings; pair it with `hcn.UpdateDNS` on the
+// endpoint to keep both layers consistent. The server addresses are validated
+// before anything is sent, and the guest applies the request as a single
+// modification, so a failure leaves the previous DNS configuration intact.
+func (uvm *UtilityVM) UpdateGuestDNS(ctx context.Context, id string, servers []string, suffix string) error {
+	if uvm.operatingSystem != "linux" {
+		return errNotSupported
+	}
+	if len(servers) == 0 {
+		return errors.New("at least one DNS server address is required")
+	}
+	for _, server := range servers {
+		if net.ParseIP(server) == nil {
+			return fmt.Errorf("invalid DNS server address %q", server)
+		}
+	}
+
+	request := hcsschema.ModifySettingRequest{}
+	request.GuestRequest = guestrequest.ModificationRequest{
+		ResourceType: guestresource.ResourceTypeNetwork,
+		RequestType:  guestrequest.RequestTypeUpdate,
+		Settings: &guestresource.LCOWNetworkAdapter{
+			ID:            id,
+			DNSSuffix:     suffix,
+			DNSServerList: strings.Join(servers, ","),
+		},
+	}
+
+	return uvm.modify(ctx, &request)
+}
+
 // AddNICInGuest makes a request to setup a network adapter's interface inside the lcow guest.
 // This is primarily used for adding NICs in the guest that have been VPCI assigned.
 func (uvm *UtilityVM) AddNICInGuest(ctx context.Context, cfg *guestresource.LCOWNetworkAdapter) error {
@@ -209,6 +209,12 @@ const (
 	// CPUGroupID specifies the cpugroup ID that a UVM should be assigned to, if any.
 	CPUGroupID = "io.microsoft.virtualmachine.cpugroup.id"
 
+	// CPUGroupNumaNode specifies the host NUMA node whose logical processors a
+	// UVM's cpugroup should be built from. A cpugroup covering the node's
+	// processors is created (or reused) at pod creation and the UVM is
+	// assigned to it. Cannot be combined with CPUGroupID.
+	CPUGroupNumaNode = "io.microsoft.virtualmachine.cpugroup.numanode"
+
 	// NetworkConfigProxy holds the address of the network config proxy service.
 	// If set, network setup will be attempted via ncproxy.
 	NetworkConfigProxy = "io.microsoft.network.ncproxy"
@@ -0,0 +1,254 @@
+//go:build windows
+
+// Package cnmdriver adapts the HCN API to the Container Network Model (CNM)
+// driver contract used by Docker's libnetwork, so these primitives can back a
+// libnetwork network driver without this repository depending on libnetwork.
+// The request and response types mirror the remote driver wire protocol;
+// callers embed the Driver behind their plugin transport of choice.
+//
+// CNM object IDs are recorded as the friendly names of the HCN objects the
+// driver creates, so the adapter itself is stateless: every callback resolves
+// its objects by name through the service.
+package cnmdriver
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/netip"
+	"strings"
+
+	"github.com/Microsoft/hcsshim/hcn"
+)
+
+// Option keys accepted in CreateNetworkRequest.Options, matching the keys the
+// Windows libnetwork shim uses.
+const (
+	// NetworkTypeOption selects the HCN network type (NAT, Transparent,
+	// L2Bridge, L2Tunnel, ICS, Private, Overlay). Defaults to NAT.
+	NetworkTypeOption = "com.docker.network.windowsshim.networktype"
+	// InterfaceOption binds the network to a specific host network adapter.
+	InterfaceOption = "com.docker.network.windowsshim.interface"
+)
+
+// IPAMData carries one address pool from libnetwork's IPAM.
+type IPAMData struct {
+	AddressSpace string
+	Pool         string
+	Gateway      string
+}
+
+// CreateNetworkRequest is the CNM create-network callback payload.
+type CreateNetworkRequest struct {
+	NetworkID string
+	Options   map[string]string
+	IPv4Data  []IPAMData
+}
+
+// EndpointInterface describes an endpoint's interface in CNM terms. Fields
+// left empty on create are assigned by HNS and reported back in the response.
+type EndpointInterface struct {
+	Address    string // CIDR
+	MacAddress string
+}
+
+// CreateEndpointRequest is the CNM create-endpoint callback payload.
+type CreateEndpointRequest struct {
+	NetworkID  string
+	EndpointID string
+	Interface  *EndpointInterface
+}
+
+// CreateEndpointResponse reports the interface configuration HNS assigned.
+type CreateEndpointResponse struct {
+	Interface *EndpointInterface
+}
+
+// JoinRequest is the CNM join callback payload.
+type JoinRequest struct {
+	NetworkID  string
+	EndpointID string
+	SandboxKey string
+}
+
+// JoinResponse reports how the sandbox should use the endpoint.
+type JoinResponse struct {
+	// DisableGatewayService is set because HNS programs the endpoint's
+	// gateway itself; libnetwork must not add its own.
+	DisableGatewayService bool
+	Gateway               string
+}
+
+// Driver maps CNM network and endpoint lifecycle callbacks onto
+// HostComputeNetwork and HostComputeEndpoint operations.
+type Driver struct{}
+
+// NewDriver returns a Driver backed by the HCN service on this host.
+func NewDriver() *Driver {
+	return &Driver{}
+}
+
+// CreateNetwork creates an HCN network named after the CNM network ID, with
+// the type and IPAM configuration translated from the request.
+func (d *Driver) CreateNetwork(req *CreateNetworkRequest) error {
+	networkType := hcn.NAT
+	if t, ok := req.Options[NetworkTypeOption]; ok {
+		networkType = hcn.NetworkType(t)
+	}
+
+	network := &hcn.HostComputeNetwork{
+		Name: req.NetworkID,
+		Type: networkType,
+		SchemaVersion: hcn.SchemaVersion{
+			Major: 2,
+			Minor: 0,
+		},
+	}
+	if adapter, ok := req.Options[InterfaceOption]; ok {
+		policy, err := networkAdapterPolicy(adapter)
+		if err != nil {
+			return err
+		}
+		network.Policies = append(network.Policies, *policy)
+	}
+	for _, data := range req.IPv4Data {
+		subnet := hcn.Subnet{IpAddressPrefix: data.Pool}
+		if data.Gateway != "" {
+			// libnetwork hands the gateway through in CIDR form.
+			gateway, _, _ := strings.Cut(data.Gateway, "/")
+			subnet.Routes = []hcn.Route{{
+				NextHop:           gateway,
+				DestinationPrefix: "0.0.0.0/0",
+			}}
+		}
+		network.Ipams = append(network.Ipams, hcn.Ipam{
+			Type:    "Static",
+			Subnets: []hcn.Subnet{subnet},
+		})
+	}
+
+	_, err := network.Create()
+	return err
+}
+
+// DeleteNetwork deletes the HCN network backing the CNM network.
+func (d *Driver) DeleteNetwork(networkID string) error {
+	network, err := hcn.GetNetworkByName(networkID)
+	if err != nil {
+		return err
+	}
+	return network.Delete()
+}
+
+// CreateEndpoint creates an HCN endpoint named after the CNM endpoint ID on
+// the network's backing HCN network, and reports the address and MAC the
+// endpoint ended up with.
+func (d *Driver) CreateEndpoint(req *CreateEndpointRequest) (*CreateEndpointResponse, error) {
+	network, err := hcn.GetNetworkByName(req.NetworkID)
+	if err != nil {
+		return nil, err
+	}
+
+	endpoint := &hcn.HostComputeEndpoint{
+		Name:          req.EndpointID,
+		SchemaVersion: network.SchemaVersion,
+	}
+	if req.Interface != nil {
+		if req.Interface.Address != "" {
+			ipConfig, err := parseCIDR(req.Interface.Address)
+			if err != nil {
+				return nil, err
+			}
+			endpoint.IpConfigurations = []hcn.IpConfig{*ipConfig}
+		}
+		endpoint.MacAddress = req.Interface.MacAddress
+	}
+
+	created, err := network.CreateEndpoint(endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &CreateEndpointResponse{Interface: &EndpointInterface{
+		MacAddress: created.MacAddress,
+	}}
+	if len(created.IpConfigurations) > 0 {
+		ipConfig := created.IpConfigurations[0]
+		response.Interface.Address = fmt.Sprintf("%s/%d", ipConfig.IpAddress, ipConfig.PrefixLength)
+	}
+	return response, nil
+}
+
+// DeleteEndpoint deletes the HCN endpoint backing the CNM endpoint.
+func (d *Driver) DeleteEndpoint(endpointID string) error {
+	endpoint, err := hcn.GetEndpointByName(endpointID)
+	if err != nil {
+		return err
+	}
+	return endpoint.Delete()
+}
+
+// Join reports the gateway for the endpoint's sandbox. Attachment itself is
+// done by HNS when the endpoint is added to the container's namespace, so the
+// response tells libnetwork not to program its own gateway.
+func (d *Driver) Join(req *JoinRequest) (*JoinResponse, error) {
+	endpoint, err := hcn.GetEndpointByName(req.EndpointID)
+	if err != nil {
+		return nil, err
+	}
+	response := &JoinResponse{DisableGatewayService: true}
+	for _, route := range endpoint.Routes {
+		if route.NextHop != "" {
+			response.Gateway = route.NextHop
+			break
+		}
+	}
+	return response, nil
+}
+
+// Leave is a no-op: detachment happens when the endpoint leaves the
+// container's namespace.
+func (d *Driver) Leave(req *JoinRequest) error {
+	if _, err := hcn.GetEndpointByName(req.EndpointID); err != nil {
+		return err
+	}
+	return nil
+}
+
+// EndpointOperInfo reports operational data for the endpoint, keyed the way
+// libnetwork expects.
+func (d *Driver) EndpointOperInfo(endpointID string) (map[string]interface{}, error) {
+	endpoint, err := hcn.GetEndpointByName(endpointID)
+	if err != nil {
+		return nil, err
+	}
+	info := map[string]interface{}{
+		"hnsid":      endpoint.Id,
+		"MacAddress": endpoint.MacAddress,
+	}
+	if len(endpoint.IpConfigurations) > 0 {
+		info["Address"] = endpoint.IpConfigurations[0].IpAddress
+	}
+	return info, nil
+}
+
+func networkAdapterPolicy(adapter string) (*hcn.NetworkPolicy, error) {
+	settings, err := json.Marshal(hcn.NetAdapterNameNetworkPolicySetting{NetworkAdapterName: adapter})
+	if err != nil {
+		return nil, err
+	}
+	return &hcn.NetworkPolicy{
+		Type:     hcn.NetAdapterName,
+		Settings: settings,
+	}, nil
+}
+
+func parseCIDR(address string) (*hcn.IpConfig, error) {
+	prefix, err := netip.ParsePrefix(address)
+	if err != nil {
+		return nil, fmt.Errorf("invalid endpoint address %q: %w", address, err)
+	}
+	return &hcn.IpConfig{
+		IpAddress:    prefix.Addr().String(),
+		PrefixLength: uint8(prefix.Bits()),
+	}, nil
+}
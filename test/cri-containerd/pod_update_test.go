@@ -182,7 +182,9 @@ func Test_Pod_UpdateResources_CPUShares(t *testing.T) {
 }
 
 func Test_Pod_UpdateResources_CPUGroup(t *testing.T) {
-	t.Skip("Skipping for now")
+	// The cpugroup APIs expose per-group configuration but not which VMs are
+	// members of a group, so the update cannot be verified end to end here.
+	t.Skip("cpugroup membership is not observable through the available APIs")
 	requireAnyFeature(t, featureWCOWHypervisor)
 
 	ctx := context.Background()